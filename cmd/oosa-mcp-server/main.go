@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	stdlog "log"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Bryanlin920616/oosa-mcp-server/config"
 	iolog "github.com/Bryanlin920616/oosa-mcp-server/pkg/log"
@@ -27,8 +29,10 @@ type ServerTransport string
 const (
 	// ServerTransportStdio 使用標準輸入輸出進行通信
 	ServerTransportStdio ServerTransport = "stdio"
-	// ServerTransportSSE 使用 SSE 進行通信
+	// ServerTransportSSE 使用 SSE 進行通信（舊版用戶端）
 	ServerTransportSSE ServerTransport = "sse"
+	// ServerTransportHTTP 使用 streamable HTTP 進行通信，支援 session 與串流續傳
+	ServerTransportHTTP ServerTransport = "http"
 )
 
 var (
@@ -42,7 +46,7 @@ var (
 	serverCmd = &cobra.Command{
 		Use:   "serve",
 		Short: "Start the server",
-		Long:  `Start the server in the specified mode (stdio or sse)`,
+		Long:  `Start the server in the specified mode (stdio, sse or http)`,
 		Run: func(_ *cobra.Command, _ []string) {
 			logFile := viper.GetString("log-file")
 			logger, err := initLogger(logFile)
@@ -56,6 +60,63 @@ var (
 				transport:   ServerTransport(viper.GetString("server.transport")),
 				addr:        viper.GetString("server.addr"),
 				baseURL:     viper.GetString("server.base_url"),
+				endpoint:    viper.GetString("server.endpoint"),
+				backend: oosa.BackendConfig{
+					Kind: oosa.BackendKind(viper.GetString("backend")),
+					Mongo: oosa.MongoConfig{
+						URI:      viper.GetString("mongo.uri"),
+						Database: viper.GetString("mongo.database"),
+						Timeout:  viper.GetDuration("mongo.timeout"),
+					},
+					HTTP: oosa.HTTPConfig{
+						BaseURL:    viper.GetString("http.base_url"),
+						AuthHeader: viper.GetString("http.auth_header"),
+						AuthToken:  viper.GetString("http.auth_token"),
+						Timeout:    viper.GetDuration("http.timeout"),
+					},
+					FilePath: viper.GetString("file.path"),
+				},
+			}
+
+			var tokens []struct {
+				Token  string `mapstructure:"token"`
+				UserID string `mapstructure:"user_id"`
+			}
+			if err := viper.UnmarshalKey("auth.tokens", &tokens); err != nil {
+				stdlog.Fatal("invalid auth.tokens:", err)
+			}
+			cfg.auth = oosa.AuthConfig{
+				UserID: viper.GetString("auth.user_id"),
+				Tokens: make(map[string]string, len(tokens)),
+			}
+			for _, t := range tokens {
+				cfg.auth.Tokens[t.Token] = t.UserID
+			}
+
+			cfg.bounds = oosa.DefaultBounds
+			if viper.IsSet("events.bounds") {
+				cfg.bounds = oosa.Bounds{
+					MinLat: viper.GetFloat64("events.bounds.min_lat"),
+					MinLng: viper.GetFloat64("events.bounds.min_lng"),
+					MaxLat: viper.GetFloat64("events.bounds.max_lat"),
+					MaxLng: viper.GetFloat64("events.bounds.max_lng"),
+				}
+			}
+
+			cfg.indexRefresh = oosa.DefaultIndexRefreshInterval
+			if viper.IsSet("events.index_refresh_interval") {
+				cfg.indexRefresh = viper.GetDuration("events.index_refresh_interval")
+			}
+
+			cfg.pollInterval = viper.GetDuration("subscriptions.poll_interval")
+
+			cfg.sessionTimeout = oosa.DefaultSessionIdleTimeout
+			if viper.IsSet("server.session_timeout") {
+				cfg.sessionTimeout = viper.GetDuration("server.session_timeout")
+			}
+			cfg.shutdownTimeout = oosa.DefaultDrainTimeout
+			if viper.IsSet("server.shutdown_timeout") {
+				cfg.shutdownTimeout = viper.GetDuration("server.shutdown_timeout")
 			}
 
 			if err := runServer(cfg); err != nil {
@@ -72,16 +133,20 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.oosa-mcp-server.yaml)")
 
 	// 設定 server 相關的 flag
-	serverCmd.Flags().StringP("transport", "t", "stdio", "服務器傳輸方式 (stdio 或 sse)")
-	serverCmd.Flags().StringP("addr", "a", "0.0.0.0:8080", "SSE 服務器監聽地址")
+	serverCmd.Flags().StringP("transport", "t", "stdio", "服務器傳輸方式 (stdio, sse 或 http)")
+	serverCmd.Flags().StringP("addr", "a", "0.0.0.0:8080", "SSE 與 HTTP 服務器監聽地址")
 	serverCmd.Flags().StringP("base-url", "b", "http://localhost:8080", "SSE 服務器 base URL（用於 origin 驗證）")
+	serverCmd.Flags().String("endpoint", oosa.DefaultEndpointPath, "HTTP 模式的 MCP endpoint 路徑")
+	serverCmd.Flags().String("backend", "", "資料來源 (mock, mongo, http 或 file)")
 	serverCmd.Flags().String("log-file", "", "Path to log file")
 	serverCmd.Flags().Bool("enable-command-logging", false, "When enabled, the server will log all command requests and responses")
 
 	// 綁定 flag 到 viper
-	_ = viper.BindPFlag("server.mode", serverCmd.Flags().Lookup("transport"))
+	_ = viper.BindPFlag("server.transport", serverCmd.Flags().Lookup("transport"))
 	_ = viper.BindPFlag("server.addr", serverCmd.Flags().Lookup("addr"))
 	_ = viper.BindPFlag("server.base_url", serverCmd.Flags().Lookup("base-url"))
+	_ = viper.BindPFlag("server.endpoint", serverCmd.Flags().Lookup("endpoint"))
+	_ = viper.BindPFlag("backend", serverCmd.Flags().Lookup("backend"))
 	_ = viper.BindPFlag("log-file", serverCmd.Flags().Lookup("log-file"))
 	_ = viper.BindPFlag("enable-command-logging", serverCmd.Flags().Lookup("enable-command-logging"))
 
@@ -152,6 +217,18 @@ type runConfig struct {
 	transport   ServerTransport
 	addr        string
 	baseURL     string
+	endpoint    string
+	backend     oosa.BackendConfig
+	auth        oosa.AuthConfig
+	bounds      oosa.Bounds
+	// indexRefresh is how often the search and nearby indexes pick up changes made outside this server
+	indexRefresh time.Duration
+	// pollInterval is how often the backend is checked for changes to report to subscribers
+	pollInterval time.Duration
+	// sessionTimeout is how long an idle streamable HTTP session is kept
+	sessionTimeout time.Duration
+	// shutdownTimeout is how long running requests may take to finish once the server is stopping
+	shutdownTimeout time.Duration
 }
 
 func runServer(cfg runConfig) error {
@@ -159,23 +236,60 @@ func runServer(cfg runConfig) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Create client like github, mongodb, etc.
-	client := &oosa.Client{} // TODO: use real client
+	// Create backend like mongodb, http, etc.
+	if cfg.backend.Kind == "" {
+		cfg.backend.Kind = oosa.BackendMock
+		if cfg.backend.Mongo.URI != "" {
+			cfg.backend.Kind = oosa.BackendMongo
+		}
+	}
+	backend, err := oosa.NewBackend(ctx, cfg.backend)
+	if err != nil {
+		return fmt.Errorf("failed to create %s backend: %w", cfg.backend.Kind, err)
+	}
+	defer func() {
+		if err := backend.Close(context.Background()); err != nil {
+			cfg.logger.Errorf("failed to close backend: %v", err)
+		}
+	}()
+	cfg.logger.Infof("Using %s backend", cfg.backend.Kind)
 
 	// Create server
-	mcpServer := oosa.NewServer(client, config.Version)
+	subscriptions := oosa.NewSubscriptions()
+	drain := oosa.NewDrain()
+	mcpServer := oosa.NewServer(backend, config.Version,
+		oosa.WithBounds(cfg.bounds),
+		oosa.WithIndexRefresh(cfg.indexRefresh),
+		oosa.WithSubscriptions(subscriptions),
+		oosa.WithDrain(drain),
+	)
+	go subscriptions.Watch(ctx, backend, cfg.pollInterval, func(err error) {
+		cfg.logger.Warn(err)
+	})
+	authenticator := oosa.NewAuthenticator(cfg.auth)
 
 	// Create error logger
 	stdLogger := stdlog.New(cfg.logger.Writer(), "server", 0)
 
 	// Start listening for messages
 	errC := make(chan error, 1)
+	httpServer := &http.Server{Addr: cfg.addr} // SSE 與 HTTP 模式 listen 的地址
+	var streamable *oosa.StreamableHTTPServer
+	if cfg.transport == ServerTransportHTTP {
+		streamable = oosa.NewStreamableHTTPServer(mcpServer,
+			oosa.WithEndpointPath(cfg.endpoint),
+			oosa.WithHTTPContextFunc(authenticator.SSEContext),
+			oosa.WithSessionIdleTimeout(cfg.sessionTimeout),
+			subscriptions.StreamableHTTP(),
+		)
+	}
 	go func() {
 		switch cfg.transport {
 		case ServerTransportStdio:
 			// 建立 stdio server
 			stdioServer := server.NewStdioServer(mcpServer)
 			stdioServer.SetErrorLogger(stdLogger)
+			stdioServer.SetContextFunc(authenticator.StdioContext)
 			cfg.logger.Info("Starting server in stdio mode")
 
 			// 設定輸入輸出
@@ -185,6 +299,7 @@ func runServer(cfg runConfig) error {
 				loggedIO := iolog.NewIOLogger(in, out, cfg.logger)
 				in, out = loggedIO, loggedIO
 			}
+			in, out = subscriptions.StdioTransport(in, out)
 
 			errC <- stdioServer.Listen(ctx, in, out)
 
@@ -193,10 +308,20 @@ func runServer(cfg runConfig) error {
 			cfg.logger.Infof("Set base URL: %s", cfg.baseURL)
 			sseServer := server.NewSSEServer(mcpServer,
 				server.WithBaseURL(cfg.baseURL),
+				server.WithSSEContextFunc(authenticator.SSEContext),
+				server.WithHTTPServer(httpServer),
 			)
+			httpServer.Handler = drain.Handler(subscriptions.SSEHandler(sseServer))
 			cfg.logger.Infof("Starting server in SSE mode on %s", cfg.addr)
 
-			errC <- sseServer.Start(cfg.addr) // server listen 的地址
+			errC <- httpServer.ListenAndServe()
+
+		case ServerTransportHTTP:
+			// 建立 streamable HTTP server
+			httpServer.Handler = drain.Handler(streamable)
+			cfg.logger.Infof("Starting server in streamable HTTP mode on %s%s", cfg.addr, cfg.endpoint)
+
+			errC <- httpServer.ListenAndServe()
 
 		default:
 			errC <- fmt.Errorf("unsupported server transport: %s", cfg.transport)
@@ -210,6 +335,9 @@ func runServer(cfg runConfig) error {
 	select {
 	case <-ctx.Done():
 		cfg.logger.Infof("shutting down server...")
+		if cfg.transport == ServerTransportSSE || cfg.transport == ServerTransportHTTP {
+			shutdownHTTP(cfg, httpServer, drain, streamable)
+		}
 	case err := <-errC:
 		if err != nil {
 			return fmt.Errorf("error running server: %w", err)
@@ -219,6 +347,31 @@ func runServer(cfg runConfig) error {
 	return nil
 }
 
+// shutdownHTTP stops an SSE or HTTP server without cutting off its clients:
+// new sessions are refused, running tool calls get cfg.shutdownTimeout to
+// finish, and clients are told the server is going away before it closes.
+// streamable is nil in SSE mode.
+func shutdownHTTP(cfg runConfig, httpServer *http.Server, drain *oosa.Drain, streamable *oosa.StreamableHTTPServer) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+	if err := drain.Shutdown(ctx); err != nil {
+		cfg.logger.Warnf("failed to drain server: %v", err)
+	}
+	// Closing the sessions ends their streams and stops the session expiry.
+	if streamable != nil {
+		if err := streamable.Shutdown(ctx); err != nil {
+			cfg.logger.Warnf("failed to close sessions: %v", err)
+		}
+	}
+
+	// Event streams never go idle, so http.Server.Shutdown would wait them
+	// out. Their clients have had the notice by now, so close them.
+	if err := httpServer.Close(); err != nil {
+		cfg.logger.Errorf("failed to close server: %v", err)
+	}
+	cfg.logger.Infof("server stopped")
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,342 @@
+package oosa
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool arguments can be declared once, as a struct whose fields carry an arg
+// tag, and then both advertised with WithArgs and decoded with BindArgs:
+//
+//	type searchArgs struct {
+//		Query string `arg:"query,required" desc:"Search terms"`
+//		Limit int    `arg:"limit,min=1,max=50,default=10" desc:"Maximum number of results"`
+//	}
+//
+// The arg tag holds the argument name followed by any of required, min=N,
+// max=N, default=V and enum=a|b|c. desc is the description shown to clients.
+// Fields may be string, bool, int, float64 or []string, or a string type
+// implementing argEnum. Fields of embedded structs are arguments too, so
+// groups of arguments can be shared.
+
+// argEnum is implemented by string argument types with a fixed set of values.
+// The schema lists Enum, and ParseArg turns any spelling the type accepts
+// into one of them.
+type argEnum interface {
+	Enum() []string
+	ParseArg(s string) (string, error)
+}
+
+// argField is one argument declared by a struct field.
+type argField struct {
+	index       []int
+	kind        reflect.Kind
+	name        string
+	description string
+	required    bool
+	min, max    *float64
+	def         *reflect.Value
+	enum        []string
+	parse       func(string) (string, error)
+}
+
+// argFieldsCache holds the []argField of every struct type seen.
+var argFieldsCache sync.Map
+
+// argFieldsOf returns the arguments declared by the struct type t. Malformed
+// tags are programming errors and panic, as tools are built at startup.
+func argFieldsOf(t reflect.Type) []argField {
+	if cached, ok := argFieldsCache.Load(t); ok {
+		return cached.([]argField)
+	}
+
+	var fields []argField
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("arg")
+		if !ok || sf.Anonymous {
+			continue
+		}
+		f, err := parseArgField(sf, tag)
+		if err != nil {
+			panic(fmt.Sprintf("oosa: field %s of %s: %v", sf.Name, t, err))
+		}
+		fields = append(fields, f)
+	}
+
+	argFieldsCache.Store(t, fields)
+	return fields
+}
+
+func parseArgField(sf reflect.StructField, tag string) (argField, error) {
+	f := argField{
+		index:       sf.Index,
+		kind:        sf.Type.Kind(),
+		description: sf.Tag.Get("desc"),
+	}
+	switch {
+	case f.kind == reflect.String, f.kind == reflect.Bool, f.kind == reflect.Int, f.kind == reflect.Float64:
+	case f.kind == reflect.Slice && sf.Type.Elem().Kind() == reflect.String:
+	default:
+		return argField{}, fmt.Errorf("unsupported type %s", sf.Type)
+	}
+
+	if e, ok := reflect.Zero(sf.Type).Interface().(argEnum); ok && f.kind == reflect.String {
+		f.enum = e.Enum()
+		f.parse = e.ParseArg
+	}
+
+	parts := strings.Split(tag, ",")
+	f.name = parts[0]
+	if f.name == "" {
+		return argField{}, fmt.Errorf("missing argument name")
+	}
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "required":
+			f.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return argField{}, fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "min" {
+				f.min = &n
+			} else {
+				f.max = &n
+			}
+		case "enum":
+			f.enum = strings.Split(value, "|")
+		case "default":
+			def := reflect.New(sf.Type).Elem()
+			if err := setArgDefault(def, value); err != nil {
+				return argField{}, fmt.Errorf("invalid default %q: %w", value, err)
+			}
+			f.def = &def
+		default:
+			return argField{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return f, nil
+}
+
+func setArgDefault(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("defaults are not supported for %s", v.Type())
+	}
+	return nil
+}
+
+// describe completes the description with the bounds and default, which
+// not every client reads from the schema.
+func (f argField) describe() string {
+	var hints []string
+	if f.min != nil {
+		hints = append(hints, "min "+strconv.FormatFloat(*f.min, 'f', -1, 64))
+	}
+	if f.max != nil {
+		hints = append(hints, "max "+strconv.FormatFloat(*f.max, 'f', -1, 64))
+	}
+	if f.def != nil {
+		hints = append(hints, fmt.Sprintf("default %v", f.def.Interface()))
+	}
+	if len(hints) == 0 {
+		return f.description
+	}
+	return strings.TrimSpace(f.description + " (" + strings.Join(hints, ", ") + ")")
+}
+
+// option returns the ToolOption advertising f.
+func (f argField) option() mcp.ToolOption {
+	props := []mcp.PropertyOption{mcp.Description(f.describe())}
+	if f.required {
+		props = append(props, mcp.Required())
+	}
+	if f.min != nil {
+		props = append(props, mcp.Min(*f.min))
+	}
+	if f.max != nil {
+		props = append(props, mcp.Max(*f.max))
+	}
+	if len(f.enum) > 0 {
+		props = append(props, mcp.Enum(f.enum...))
+	}
+
+	switch f.kind {
+	case reflect.Bool:
+		if f.def != nil {
+			props = append(props, mcp.DefaultBool(f.def.Bool()))
+		}
+		return mcp.WithBoolean(f.name, props...)
+	case reflect.Int:
+		props = append(props, func(schema map[string]any) {
+			schema["type"] = "integer"
+		})
+		if f.def != nil {
+			props = append(props, mcp.DefaultNumber(float64(f.def.Int())))
+		}
+		return mcp.WithNumber(f.name, props...)
+	case reflect.Float64:
+		if f.def != nil {
+			props = append(props, mcp.DefaultNumber(f.def.Float()))
+		}
+		return mcp.WithNumber(f.name, props...)
+	case reflect.Slice:
+		props = append(props, mcp.Items(map[string]any{"type": "string"}))
+		return mcp.WithArray(f.name, props...)
+	default:
+		if f.def != nil {
+			props = append(props, mcp.DefaultString(f.def.String()))
+		}
+		return mcp.WithString(f.name, props...)
+	}
+}
+
+// WithArgs returns a ToolOption that adds the arguments declared by args, a
+// tagged struct or a pointer to one, to the tool.
+func WithArgs(args any) mcp.ToolOption {
+	fields := argFieldsOf(reflect.Indirect(reflect.ValueOf(args)).Type())
+	return func(tool *mcp.Tool) {
+		for _, f := range fields {
+			f.option()(tool)
+		}
+	}
+}
+
+// BindArgs decodes the arguments of the request into dst, a pointer to a
+// tagged struct, checking them against the tags. Missing arguments take
+// their default, or are left untouched when they have none.
+func BindArgs(r mcp.CallToolRequest, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("arguments must be bound to a pointer to a struct, not %T", dst)
+	}
+	v = v.Elem()
+
+	for _, f := range argFieldsOf(v.Type()) {
+		field := v.FieldByIndex(f.index)
+		raw, ok := r.Params.Arguments[f.name]
+		if !ok || raw == nil {
+			if f.required {
+				return fmt.Errorf("missing required parameter: %s", f.name)
+			}
+			if f.def != nil {
+				field.Set(*f.def)
+			}
+			continue
+		}
+		if err := f.bind(field, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind checks raw, the JSON-decoded value of f, and stores it in field.
+func (f argField) bind(field reflect.Value, raw any) error {
+	switch f.kind {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("parameter %s is not of type string, is %T", f.name, raw)
+		}
+		if f.required && s == "" {
+			return fmt.Errorf("missing required parameter: %s", f.name)
+		}
+		switch {
+		case f.parse != nil && s != "":
+			parsed, err := f.parse(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", f.name, err)
+			}
+			s = parsed
+		case len(f.enum) > 0 && !slices.Contains(f.enum, s):
+			return fmt.Errorf("invalid %s %q: expected one of %s", f.name, s, strings.Join(f.enum, ", "))
+		}
+		field.SetString(s)
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("parameter %s is not of type bool, is %T", f.name, raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Float64:
+		n, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("parameter %s is not of type number, is %T", f.name, raw)
+		}
+		if f.kind == reflect.Int && n != math.Trunc(n) {
+			return fmt.Errorf("%s must be a whole number, is %v", f.name, n)
+		}
+		if err := f.checkRange(n); err != nil {
+			return err
+		}
+		if f.kind == reflect.Int {
+			field.SetInt(int64(n))
+		} else {
+			field.SetFloat(n)
+		}
+	case reflect.Slice:
+		var list []string
+		switch raw := raw.(type) {
+		case []string:
+			list = raw
+		case []any:
+			list = make([]string, len(raw))
+			for i, item := range raw {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("parameter %s must be a list of strings, has %T", f.name, item)
+				}
+				list[i] = s
+			}
+		default:
+			return fmt.Errorf("parameter %s is not a list of strings, is %T", f.name, raw)
+		}
+		if f.required && len(list) == 0 {
+			return fmt.Errorf("missing required parameter: %s", f.name)
+		}
+		field.Set(reflect.ValueOf(list))
+	}
+	return nil
+}
+
+func (f argField) checkRange(n float64) error {
+	switch {
+	case f.min != nil && f.max != nil && (n < *f.min || n > *f.max):
+		return fmt.Errorf("%s must be between %v and %v, is %v", f.name, *f.min, *f.max, n)
+	case f.min != nil && n < *f.min:
+		return fmt.Errorf("%s must be at least %v, is %v", f.name, *f.min, n)
+	case f.max != nil && n > *f.max:
+		return fmt.Errorf("%s must be at most %v, is %v", f.name, *f.max, n)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by a Backend when the requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Errors returned by EventWriter when joining or leaving an event is not allowed.
+var (
+	ErrEventFull          = errors.New("event is full")
+	ErrRegistrationClosed = errors.New("registration deadline has passed")
+	ErrEventStarted       = errors.New("event has already started")
+	ErrAlreadyJoined      = errors.New("already joined this event")
+	ErrNotJoined          = errors.New("not a participant of this event")
+	ErrEventCancelled     = errors.New("event is cancelled")
+)
+
+// ErrLimitBelowParticipants is returned by UpdateEvent when the new
+// participant limit is lower than the number of people already joined.
+var ErrLimitBelowParticipants = errors.New("participant limit is below the number of participants")
+
+// EventStore provides access to OOSA events.
+type EventStore interface {
+	GetEvents(ctx context.Context, filter EventFilter) ([]Event, error)
+	// GetEvent returns the complete event, or an error wrapping ErrNotFound.
+	GetEvent(ctx context.Context, eventID string) (*EventDetail, error)
+}
+
+// EventWriter publishes OOSA events.
+type EventWriter interface {
+	// CreateEvent stores a new event and returns it with its ID, creation
+	// time and participants summary filled in. The event is expected to be
+	// validated already, with CreatedByUser set to the host.
+	CreateEvent(ctx context.Context, event EventDetail) (*EventDetail, error)
+	// UpdateEvent overwrites the editable fields of the event with ID
+	// event.ID: everything but the host, participants and bookkeeping fields.
+	// It fails with ErrEventCancelled for a cancelled event and with
+	// ErrLimitBelowParticipants when the new limit leaves too few seats.
+	UpdateEvent(ctx context.Context, event EventDetail) (*EventDetail, error)
+	// CancelEvent calls an event off, keeping reason for its participants.
+	// It fails with ErrEventCancelled when the event is already cancelled.
+	CancelEvent(ctx context.Context, eventID string, reason string) (*EventDetail, error)
+	// JoinEvent adds user to the participants of an event, taking one seat.
+	// It fails with ErrEventCancelled once the event is called off,
+	// ErrRegistrationClosed after the deadline, ErrEventFull
+	// when no seat is left and ErrAlreadyJoined for a second join. Seats are
+	// taken atomically, so concurrent joins never overbook the event.
+	JoinEvent(ctx context.Context, eventID string, user UserAgg) (*EventDetail, error)
+	// LeaveEvent removes a participant and frees their seat. It fails with
+	// ErrEventStarted once the event has begun and ErrNotJoined when the user
+	// is not a participant.
+	LeaveEvent(ctx context.Context, eventID string, userID string) (*EventDetail, error)
+}
+
+// IdeaStore provides access to OOSA ideas.
+type IdeaStore interface {
+	GetIdeas(ctx context.Context, filter IdeaFilter) ([]Idea, error)
+}
+
+// UserStore provides access to OOSA users.
+type UserStore interface {
+	GetUser(ctx context.Context, userID string) (*UserAgg, error)
+}
+
+// Backend is the data source behind the OOSA tools.
+type Backend interface {
+	EventStore
+	EventWriter
+	IdeaStore
+	UserStore
+
+	// Close releases any connection held by the backend.
+	Close(ctx context.Context) error
+}
+
+// BackendKind selects the Backend implementation.
+type BackendKind string
+
+const (
+	// BackendMock serves the built-in fixtures
+	BackendMock BackendKind = "mock"
+	// BackendMongo reads from MongoDB
+	BackendMongo BackendKind = "mongo"
+	// BackendHTTP proxies the OOSA web API
+	BackendHTTP BackendKind = "http"
+	// BackendFile reads a JSON fixture file
+	BackendFile BackendKind = "file"
+)
+
+// BackendConfig holds the settings of every Backend implementation;
+// only the section matching Kind is used.
+type BackendConfig struct {
+	Kind     BackendKind
+	Mongo    MongoConfig
+	HTTP     HTTPConfig
+	FilePath string
+}
+
+// NewBackend builds the Backend selected by cfg.Kind.
+func NewBackend(ctx context.Context, cfg BackendConfig) (Backend, error) {
+	switch cfg.Kind {
+	case BackendMock, "":
+		return NewMockClient(), nil
+	case BackendMongo:
+		return NewMongoClient(ctx, cfg.Mongo)
+	case BackendFile:
+		return NewFileClient(cfg.FilePath)
+	case BackendHTTP:
+		return NewHTTPClient(cfg.HTTP)
+	default:
+		return nil, fmt.Errorf("unsupported backend: %s", cfg.Kind)
+	}
+}
@@ -0,0 +1,35 @@
+package oosa
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewBackend(t *testing.T) {
+	ctx := context.Background()
+	for _, kind := range []BackendKind{"", BackendMock} {
+		backend, err := NewBackend(ctx, BackendConfig{Kind: kind})
+		if _, ok := backend.(*MockClient); !ok || err != nil {
+			t.Errorf("backend %q: %T, %v", kind, backend, err)
+		}
+	}
+
+	backend, err := NewBackend(ctx, BackendConfig{Kind: BackendFile, FilePath: fixturesPath})
+	if _, ok := backend.(*FileClient); !ok || err != nil {
+		t.Fatalf("file backend: %T, %v", backend, err)
+	}
+	if event, err := backend.GetEvent(ctx, "wulai"); err != nil || event.Name != "烏來溫泉一日遊" {
+		t.Errorf("file backend serves %+v, %v", event, err)
+	}
+	if _, err := NewBackend(ctx, BackendConfig{Kind: BackendFile}); err == nil {
+		t.Error("file backend without a path")
+	}
+
+	for _, kind := range []BackendKind{"sqlite", "Mock", "files"} {
+		backend, err := NewBackend(ctx, BackendConfig{Kind: kind})
+		if err == nil || !strings.Contains(err.Error(), "unsupported backend: "+string(kind)) {
+			t.Errorf("backend %q: %T, %v", kind, backend, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// errUnauthenticated is returned by callerUser when the request carries no caller.
+var errUnauthenticated = errors.New("this tool requires an authenticated user")
+
+type callerIDKey struct{}
+
+// WithCallerID returns a copy of ctx carrying the ID of the user making the request.
+func WithCallerID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, callerIDKey{}, userID)
+}
+
+// CallerIDFromContext returns the ID of the user making the request, if known.
+func CallerIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(callerIDKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// callerUser resolves the user making the request.
+func callerUser(ctx context.Context, users UserStore) (*UserAgg, error) {
+	userID, ok := CallerIDFromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	user, err := users.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: user %s does not exist", errUnauthenticated, userID)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// AuthConfig identifies the users allowed to call tools that act on their behalf.
+type AuthConfig struct {
+	// UserID is the caller in stdio mode, where the local user runs the server.
+	UserID string
+	// Tokens maps the bearer tokens accepted in SSE and HTTP modes to user IDs.
+	Tokens map[string]string
+}
+
+// Authenticator attaches the caller described by an AuthConfig to request contexts.
+type Authenticator struct {
+	cfg AuthConfig
+}
+
+// NewAuthenticator creates an Authenticator for cfg.
+func NewAuthenticator(cfg AuthConfig) *Authenticator {
+	return &Authenticator{cfg: cfg}
+}
+
+// StdioContext attaches the configured local user. It fits server.StdioContextFunc.
+func (a *Authenticator) StdioContext(ctx context.Context) context.Context {
+	if a.cfg.UserID == "" {
+		return ctx
+	}
+	return WithCallerID(ctx, a.cfg.UserID)
+}
+
+// SSEContext attaches the user owning the bearer token of r, if any.
+// It fits server.SSEContextFunc, and serves the streamable HTTP transport too.
+func (a *Authenticator) SSEContext(ctx context.Context, r *http.Request) context.Context {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ctx
+	}
+	userID, ok := a.cfg.Tokens[strings.TrimSpace(token)]
+	if !ok {
+		return ctx
+	}
+	return WithCallerID(ctx, userID)
+}
@@ -0,0 +1,105 @@
+package oosa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// CategoriesResourceURI lists the event categories.
+const CategoriesResourceURI = "oosa://categories"
+
+// Category is a kind of outing. Events store it as events_type, in the
+// Traditional Chinese label; the ID is what tools and filters use.
+type Category struct {
+	ID      string `json:"category_id"`
+	LabelZH string `json:"category_label_zh_tw"`
+	LabelEN string `json:"category_label_en"`
+	// Synonyms are other ways people write the category, in either
+	// language, that resolve to it.
+	Synonyms []string `json:"category_synonyms"`
+}
+
+// CategoryOther holds the events whose type matches no other category.
+const CategoryOther = "other"
+
+// Categories is the OOSA event taxonomy. IDs are stable; labels and
+// synonyms may grow.
+var Categories = []Category{
+	{ID: "hiking", LabelZH: "健行", LabelEN: "Hiking", Synonyms: []string{"登山", "爬山", "步道", "郊山", "hike", "trekking", "mountain"}},
+	{ID: "cultural_tour", LabelZH: "文化導覽", LabelEN: "Cultural tour", Synonyms: []string{"導覽", "文化", "老街", "古蹟", "歷史", "culture", "heritage", "walking tour"}},
+	{ID: "hot_spring", LabelZH: "溫泉", LabelEN: "Hot spring", Synonyms: []string{"泡湯", "湯屋", "hot springs", "onsen"}},
+	{ID: "food", LabelZH: "美食", LabelEN: "Food", Synonyms: []string{"小吃", "夜市", "吃貨", "food tour", "night market", "cuisine"}},
+	{ID: "night_view", LabelZH: "夜景", LabelEN: "Night view", Synonyms: []string{"賞夜景", "看夜景", "night views"}},
+	{ID: "cycling", LabelZH: "自行車", LabelEN: "Cycling", Synonyms: []string{"單車", "騎車", "腳踏車", "youbike", "bike", "bicycle"}},
+	{ID: "flowers", LabelZH: "賞花", LabelEN: "Flower viewing", Synonyms: []string{"花季", "櫻花", "flowers", "blossom"}},
+	{ID: "outdoor", LabelZH: "戶外活動", LabelEN: "Outdoors", Synonyms: []string{"戶外", "outdoor"}},
+	{ID: CategoryOther, LabelZH: "其他", LabelEN: "Other", Synonyms: []string{}},
+}
+
+// categoryIndex maps every spelling of a category, lower-cased, to it.
+var categoryIndex = func() map[string]Category {
+	index := make(map[string]Category)
+	for _, c := range Categories {
+		for _, name := range categorySpellings(c) {
+			index[strings.ToLower(name)] = c
+		}
+	}
+	return index
+}()
+
+// LookupCategory finds the category s names, by ID, label or synonym.
+func LookupCategory(s string) (Category, bool) {
+	c, ok := categoryIndex[strings.ToLower(strings.TrimSpace(s))]
+	return c, ok
+}
+
+// EventCategory returns the category of an event type; types outside the
+// taxonomy fall under CategoryOther.
+func EventCategory(eventType string) Category {
+	if c, ok := LookupCategory(eventType); ok {
+		return c
+	}
+	c, _ := LookupCategory(CategoryOther)
+	return c
+}
+
+// categorySpellings returns every way of writing c.
+func categorySpellings(c Category) []string {
+	return append([]string{c.ID, c.LabelZH, c.LabelEN}, c.Synonyms...)
+}
+
+// CategoryID is a tool argument naming a category. Any spelling known to
+// LookupCategory is accepted; the schema lists the IDs.
+type CategoryID string
+
+// Enum returns the category IDs.
+func (CategoryID) Enum() []string {
+	ids := make([]string, len(Categories))
+	for i, c := range Categories {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// ParseArg resolves any spelling of a category to its ID.
+func (CategoryID) ParseArg(s string) (string, error) {
+	c, ok := LookupCategory(s)
+	if !ok {
+		return "", fmt.Errorf("unknown category %q: expected one of %s", s, strings.Join(CategoryID("").Enum(), ", "))
+	}
+	return c.ID, nil
+}
+
+func CategoriesResource() (resource mcp.Resource, handler server.ResourceHandlerFunc) {
+	return mcp.NewResource(CategoriesResourceURI, "OOSA event categories",
+			mcp.WithResourceDescription("The kinds of events, with their stable IDs, zh-TW and English labels and synonyms. Use the IDs with the type filter of get_events and as events_type"),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return jsonResource(request.Params.URI, Categories)
+		}
+}
@@ -0,0 +1,275 @@
+package oosa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// DefaultDrainTimeout is how long a shutdown waits for running requests.
+	// It leaves room for the notice within the 10 seconds docker stop allows.
+	DefaultDrainTimeout = 8 * time.Second
+	// noticeTimeout bounds the wait for the shutdown notice to go out on the
+	// open streams. Streams of sessions that never initialized do not get it.
+	noticeTimeout = time.Second
+
+	methodLoggingMessage = "notifications/message"
+)
+
+// shutdownNotice tells clients the server is going away. It is a log
+// message, which clients show, and its data says what happened so that they
+// can reconnect.
+var shutdownNotice = mcp.JSONRPCNotification{
+	JSONRPC: mcp.JSONRPC_VERSION,
+	Notification: mcp.Notification{
+		Method: methodLoggingMessage,
+		Params: mcp.NotificationParams{
+			AdditionalFields: map[string]any{
+				"level":  mcp.LoggingLevelNotice,
+				"logger": "oosa-mcp-server",
+				"data": map[string]any{
+					"event":         "shutdown",
+					"message":       "The server is shutting down. Reconnect to continue.",
+					"message_zh_tw": "伺服器即將關閉，請重新連線。",
+				},
+			},
+		},
+	},
+}
+
+// shutdownMarker identifies shutdownNotice in what is written to a stream.
+var shutdownMarker = []byte(`"event":"shutdown"`)
+
+// Drain lets an HTTP transport shut down without cutting off its clients.
+// Its Handler wraps the transport; once Shutdown starts, new requests are
+// refused, those already running get time to finish, and then every session
+// is told the server is going away.
+type Drain struct {
+	mu       sync.Mutex
+	draining bool
+	// running counts the POSTs being served, which carry the tool calls,
+	// and the calls they left running, see holdDrain.
+	running int
+	// idle is closed when running drops to zero while draining.
+	idle     chan struct{}
+	sessions map[string]server.ClientSession
+	streams  map[*noticeWriter]struct{}
+}
+
+func NewDrain() *Drain {
+	return &Drain{
+		sessions: make(map[string]server.ClientSession),
+		streams:  make(map[*noticeWriter]struct{}),
+	}
+}
+
+// WithDrain lets d reach the sessions of the server, to send them the
+// shutdown notice.
+func WithDrain(d *Drain) ServerOption {
+	return func(o *serverOptions) {
+		o.drain = d
+	}
+}
+
+// registerSession tracks a session until the context it was registered
+// with ends. It fits server.OnRegisterSessionHookFunc.
+func (d *Drain) registerSession(ctx context.Context, session server.ClientSession) {
+	id := session.SessionID()
+
+	d.mu.Lock()
+	d.sessions[id] = session
+	d.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.sessions[id] == session {
+			delete(d.sessions, id)
+		}
+	}()
+}
+
+// Handler wraps the handler of an HTTP transport. POSTs carry the client
+// messages and are waited for on shutdown. Other requests open streams,
+// which last as long as their session and are only watched for the notice.
+func (d *Drain) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if !d.beginRequest() {
+				refuseDraining(w)
+				return
+			}
+			defer d.endRequest()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), drainKey{}, d)))
+			return
+		}
+
+		stream := d.openStream(w)
+		if stream == nil {
+			refuseDraining(w)
+			return
+		}
+		defer d.closeStream(stream)
+		next.ServeHTTP(stream, r)
+	})
+}
+
+func refuseDraining(w http.ResponseWriter) {
+	w.Header().Set("Connection", "close")
+	writeHTTPError(w, http.StatusServiceUnavailable, mcp.INTERNAL_ERROR, "the server is shutting down")
+}
+
+func (d *Drain) beginRequest() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.running++
+	return true
+}
+
+// drainKey carries the Drain of a POST in its request context.
+type drainKey struct{}
+
+// holdDrain makes the Drain of the POST whose context is ctx, if any, wait
+// for work the request leaves running after it returns, such as a tool call
+// whose client stopped reading the answer. release ends the wait. It must be
+// called while the request is being served.
+func holdDrain(ctx context.Context) (release func()) {
+	d, ok := ctx.Value(drainKey{}).(*Drain)
+	if !ok {
+		return func() {}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The request is running, so the drain cannot have gone idle yet.
+	d.running++
+	return d.endRequest
+}
+
+func (d *Drain) endRequest() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running--
+	if d.running == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+func (d *Drain) openStream(w http.ResponseWriter) *noticeWriter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil
+	}
+	stream := &noticeWriter{
+		ResponseWriter: w,
+		noticed:        make(chan struct{}),
+		closed:         make(chan struct{}),
+	}
+	d.streams[stream] = struct{}{}
+	return stream
+}
+
+func (d *Drain) closeStream(stream *noticeWriter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.streams, stream)
+	close(stream.closed)
+}
+
+// Shutdown drains the transport. New requests are refused at once, the
+// running ones have until ctx is done to finish, and then the sessions are
+// sent the shutdown notice. It returns once the notice has gone out on the
+// open streams, after which the HTTP server can be closed. The error reports
+// requests that were still running when ctx was done.
+func (d *Drain) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	idle := make(chan struct{})
+	if d.running == 0 {
+		close(idle)
+	} else {
+		d.idle = idle
+	}
+	d.mu.Unlock()
+
+	var err error
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		d.mu.Lock()
+		err = fmt.Errorf("%d requests still running: %w", d.running, ctx.Err())
+		d.mu.Unlock()
+	}
+
+	d.mu.Lock()
+	streams := slices.Collect(maps.Keys(d.streams))
+	for _, session := range d.sessions {
+		if !session.Initialized() {
+			continue
+		}
+		select {
+		case session.NotificationChannel() <- shutdownNotice:
+		default:
+		}
+	}
+	d.mu.Unlock()
+
+	timeout := time.NewTimer(noticeTimeout)
+	defer timeout.Stop()
+	for _, stream := range streams {
+		select {
+		case <-stream.noticed:
+		case <-stream.closed:
+		case <-timeout.C:
+			return err
+		}
+	}
+	return err
+}
+
+// noticeWriter is the ResponseWriter of a stream. It tells when the shutdown
+// notice has been written and flushed to the client.
+type noticeWriter struct {
+	http.ResponseWriter
+	// pending is set from the write of the notice until it is flushed. Both
+	// happen on the goroutine serving the stream.
+	pending bool
+	once    sync.Once
+	noticed chan struct{}
+	closed  chan struct{}
+}
+
+func (w *noticeWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if err == nil && bytes.Contains(p, shutdownMarker) {
+		w.pending = true
+	}
+	return n, err
+}
+
+func (w *noticeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	if w.pending {
+		w.once.Do(func() { close(w.noticed) })
+	}
+}
+
+func (w *noticeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
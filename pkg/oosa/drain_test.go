@@ -0,0 +1,73 @@
+package oosa
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingBackend holds GetEvents until release is closed.
+type blockingBackend struct {
+	Backend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBackend) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	close(b.started)
+	<-b.release
+	return b.Backend.GetEvents(ctx, filter)
+}
+
+func TestDrainWaitsForStreamedToolCalls(t *testing.T) {
+	backend := &blockingBackend{Backend: NewMockClient(), started: make(chan struct{}), release: make(chan struct{})}
+	drain := NewDrain()
+	streamable := NewStreamableHTTPServer(NewServer(backend, "test", WithDrain(drain)))
+	t.Cleanup(func() { _ = streamable.Shutdown(context.Background()) })
+	handler, served := notifyServed(drain.Handler(streamable))
+	client := newStreamableClient(t, handler)
+	<-served
+
+	streamCtx, closeStream := context.WithCancel(context.Background())
+	t.Cleanup(closeStream)
+	resp := client.do(streamCtx, http.MethodGet, "", "text/event-stream", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET: status %d", resp.StatusCode)
+	}
+	notices := readEvents(resp.Body)
+
+	// The client gives up on the answer, which leaves the call running on
+	// the session after its POST has returned.
+	callCtx, hangUp := context.WithCancel(context.Background())
+	resp = client.do(callCtx, http.MethodPost, getEventsMessage, "text/event-stream", nil)
+	<-backend.started
+	hangUp()
+	_ = resp.Body.Close()
+	<-served
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- drain.Shutdown(shutdownCtx) }()
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown did not wait for the call: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	resp = client.do(context.Background(), http.MethodPost, getEventsMessage, "application/json", nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("POST while draining: status %d", resp.StatusCode)
+	}
+
+	close(backend.release)
+	if err := <-done; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if ev := nextEvent(t, notices); !strings.Contains(ev.data, string(shutdownMarker)) {
+		t.Errorf("got %s, want the shutdown notice", ev.data)
+	}
+}
@@ -2,54 +2,404 @@ package oosa
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func GetEvents(client *Client) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+// eventFilterArgs are the get_events filters, shared by the tools that
+// select events the same way.
+type eventFilterArgs struct {
+	Past        string     `arg:"event_past" desc:"\"true\" for events that have already ended, \"false\" for upcoming or ongoing events; omit for both"`
+	PeriodBegin string     `arg:"event_period_begin" desc:"The beginning of the event period, as RFC3339 (2025-04-11T16:00:00Z) or a date (2025-04-11, Asia/Taipei unless timezone says otherwise); events ending before it are excluded"`
+	PeriodEnd   string     `arg:"event_period_end" desc:"The end of the event period, as RFC3339 or a date (inclusive, Asia/Taipei unless timezone says otherwise); events starting after it are excluded"`
+	Type        CategoryID `arg:"type" desc:"Only events of this category, see oosa://categories. Labels and synonyms such as 登山 or hiking are accepted too"`
+}
+
+// filter parses the arguments, taking dates in loc.
+func (a eventFilterArgs) filter(loc *time.Location) (EventFilter, error) {
+	filter, err := ParseEventFilter(a.Past, a.PeriodBegin, a.PeriodEnd, loc)
+	if err != nil {
+		return EventFilter{}, err
+	}
+	filter.Category = a.Type
+	return filter, nil
+}
+
+func GetEvents(store EventStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("get_events",
-			mcp.WithString("event_past",
-				mcp.Description("Filter events that occurred in the past"),
-			),
-			mcp.WithString("event_period_begin",
-				mcp.Description("The beginning of the event period"),
-			),
-			mcp.WithString("event_period_end",
-				mcp.Description("The end of the event period"),
-			),
+			mcp.WithDescription("List OOSA events ordered by start time, optionally filtered by whether they are over and by the period they take place in. Results are paginated: pass next_cursor back as cursor to get the next page"),
+			WithArgs(eventFilterArgs{}),
+			WithOutput(FormatGeoJSON),
+			WithPagination(),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			eventPast, err := OptionalParam[string](request, "event_past")
+			var args eventFilterArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request, FormatGeoJSON)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			pagination, err := OptionalPaginationParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			filter, err := args.filter(output.Location)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			events, err := store.GetEvents(ctx, filter)
 			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+				return backendError(err, "failed to get events", "無法取得活動列表").Result(), nil
 			}
 
-			eventPeriodBegin, err := OptionalParam[string](request, "event_period_begin")
+			page, err := paginateEvents(events, pagination)
 			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+				return invalidArgument(err).Result(), nil
+			}
+
+			if output.Format == FormatGeoJSON {
+				collection, err := geoJSONEventsPage(page)
+				if err != nil {
+					return internalError(err).Result(), nil
+				}
+				return renderResult(collection, output)
 			}
 
-			eventPeriodEnd, err := OptionalParam[string](request, "event_period_end")
+			return renderResult(page, output)
+		}
+}
+
+func GetEvent(store EventStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("get_event",
+			mcp.WithDescription("Get the complete details of one OOSA event, including its description, host profile and full participant list"),
+			WithArgs(eventIDArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args eventIDArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
 			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+				return invalidArgument(err).Result(), nil
 			}
 
-			// TODO: use real mongo client to get events
-			events, err := client.GetEvents(ctx, eventPast, eventPeriodBegin, eventPeriodEnd)
+			event, err := store.GetEvent(ctx, args.EventID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get events: %w", err)
+				return eventLookupError(args.EventID, err).Result(), nil
+			}
+
+			return renderResult(event, output)
+		}
+}
+
+// eventFieldArgs are the event fields a host sets. update_event takes them
+// all as optional and binds them over the current values, see
+// eventFieldArgsOf, so only the fields given change. Times are RFC3339.
+type eventFieldArgs struct {
+	Name             string     `arg:"events_name" desc:"Name of the event, e.g. 象山步道健行賞夜景"`
+	Place            string     `arg:"events_place" desc:"Name of the venue, e.g. 象山步道"`
+	Date             string     `arg:"events_date" desc:"Start time, RFC3339"`
+	DateEnd          string     `arg:"events_date_end" desc:"End time, RFC3339; after events_date"`
+	Deadline         string     `arg:"events_deadline" desc:"Registration deadline, RFC3339; before events_date"`
+	Lat              float64    `arg:"events_lat,min=-90,max=90" desc:"Latitude of the venue"`
+	Lng              float64    `arg:"events_lng,min=-180,max=180" desc:"Longitude of the venue"`
+	ParticipantLimit float64    `arg:"events_participant_limit,min=1,max=2147483647" desc:"Maximum number of participants, a positive whole number"`
+	Type             CategoryID `arg:"events_type" desc:"Kind of outing, a category ID from oosa://categories, e.g. hiking, cultural_tour, food; labels such as 健行 are accepted too"`
+	eventExtraArgs
+}
+
+// createEventArgs are eventFieldArgs with the fields an event cannot do
+// without required. The two convert into each other, so the fields must
+// stay in the same order.
+type createEventArgs struct {
+	Name             string     `arg:"events_name,required" desc:"Name of the event, e.g. 象山步道健行賞夜景"`
+	Place            string     `arg:"events_place,required" desc:"Name of the venue, e.g. 象山步道"`
+	Date             string     `arg:"events_date,required" desc:"Start time, RFC3339"`
+	DateEnd          string     `arg:"events_date_end,required" desc:"End time, RFC3339; after events_date"`
+	Deadline         string     `arg:"events_deadline,required" desc:"Registration deadline, RFC3339; before events_date"`
+	Lat              float64    `arg:"events_lat,required,min=-90,max=90" desc:"Latitude of the venue"`
+	Lng              float64    `arg:"events_lng,required,min=-180,max=180" desc:"Longitude of the venue"`
+	ParticipantLimit float64    `arg:"events_participant_limit,required,min=1,max=2147483647" desc:"Maximum number of participants, a positive whole number"`
+	Type             CategoryID `arg:"events_type,required" desc:"Kind of outing, a category ID from oosa://categories, e.g. hiking, cultural_tour, food; labels such as 健行 are accepted too"`
+	eventExtraArgs
+}
+
+// eventExtraArgs are the event fields that are optional for create_event too.
+type eventExtraArgs struct {
+	MeetingPointName string  `arg:"events_meeting_point_name" desc:"Where participants meet, e.g. 象山捷運站2號出口; defaults to the venue"`
+	MeetingPointLat  float64 `arg:"events_meeting_point_lat,min=-90,max=90" desc:"Latitude of the meeting point; defaults to the venue"`
+	MeetingPointLng  float64 `arg:"events_meeting_point_lng,min=-180,max=180" desc:"Longitude of the meeting point; defaults to the venue"`
+	Photo            string  `arg:"events_photo" desc:"URL of a cover photo"`
+	Description      string  `arg:"events_description" desc:"Full description shown on the event page"`
+	PaymentRequired  bool    `arg:"events_payment_required" desc:"Whether participants pay a fee (default false)"`
+	PaymentFee       float64 `arg:"events_payment_fee,min=0,max=2147483647" desc:"Fee per participant in TWD; required and greater than 0 when events_payment_required is true, 0 otherwise"`
+}
+
+// eventFieldArgsOf returns the fields of event as arguments. The category
+// is left empty, which apply reads as unchanged, as it may be a label
+// LookupCategory does not know.
+func eventFieldArgsOf(event EventDetail) eventFieldArgs {
+	formatTime := func(t Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	return eventFieldArgs{
+		Name:             event.Name,
+		Place:            event.Place,
+		Date:             formatTime(event.Date),
+		DateEnd:          formatTime(event.DateEnd),
+		Deadline:         formatTime(event.Deadline),
+		Lat:              event.Lat,
+		Lng:              event.Lng,
+		ParticipantLimit: event.ParticipantLimit,
+		eventExtraArgs: eventExtraArgs{
+			MeetingPointName: event.MeetingPointName,
+			MeetingPointLat:  event.MeetingPointLat,
+			MeetingPointLng:  event.MeetingPointLng,
+			Photo:            event.Photo,
+			Description:      event.Description,
+			PaymentRequired:  event.PaymentRequired != 0,
+			PaymentFee:       event.PaymentFee,
+		},
+	}
+}
+
+// apply copies the fields onto event.
+func (a eventFieldArgs) apply(event *EventDetail) error {
+	times := []struct {
+		name  string
+		value string
+		dst   *Time
+	}{
+		{"events_date", a.Date, &event.Date},
+		{"events_date_end", a.DateEnd, &event.DateEnd},
+		{"events_deadline", a.Deadline, &event.Deadline},
+	}
+	for _, t := range times {
+		parsed, err := ParseTime(t.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", t.name, t.value, err)
+		}
+		*t.dst = parsed
+	}
+
+	event.Name = a.Name
+	event.Place = a.Place
+	event.Lat, event.Lng = a.Lat, a.Lng
+	event.ParticipantLimit = a.ParticipantLimit
+	if a.Type != "" {
+		event.Type = ""
+		if c, ok := LookupCategory(string(a.Type)); ok {
+			event.Type = c.LabelZH
+		}
+	}
+	event.MeetingPointName = a.MeetingPointName
+	event.MeetingPointLat, event.MeetingPointLng = a.MeetingPointLat, a.MeetingPointLng
+	event.Photo = a.Photo
+	event.Description = a.Description
+	event.PaymentRequired = 0
+	if a.PaymentRequired {
+		event.PaymentRequired = 1
+	}
+	event.PaymentFee = a.PaymentFee
+	return nil
+}
+
+// normalizeEventTimes stores the times of event in UTC once validated.
+func normalizeEventTimes(event *EventDetail) {
+	event.Date = NewTime(event.Date.UTC())
+	event.DateEnd = NewTime(event.DateEnd.UTC())
+	event.Deadline = NewTime(event.Deadline.UTC())
+}
+
+// CreateEvent publishes events, which must lie inside bounds and must not
+// start or close registration before now.
+func CreateEvent(store EventWriter, users UserStore, bounds Bounds, now func() time.Time) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("create_event",
+			mcp.WithDescription("Publish a new OOSA event hosted by the authenticated user. Times are RFC3339 (e.g. 2025-04-11T16:00:00+08:00); the registration deadline must be in the future and come before the start, and the start before the end"),
+			WithArgs(createEventArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args createEventArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
 			}
 
-			// TODO: StatusCode check
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
 
-			r, err := json.Marshal(events)
+			host, err := callerUser(ctx, users)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal events: %w", err)
+				return callerError(err).Result(), nil
+			}
+
+			var event EventDetail
+			if err := eventFieldArgs(args).apply(&event); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			if event.MeetingPointName == "" {
+				event.MeetingPointName = event.Place
+			}
+			if event.MeetingPointLat == 0 && event.MeetingPointLng == 0 {
+				event.MeetingPointLat, event.MeetingPointLng = event.Lat, event.Lng
+			}
+
+			if err := validateNewEvent(event.Event, bounds, now()); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			normalizeEventTimes(&event)
+			event.CreatedByUser = host
+
+			created, err := store.CreateEvent(ctx, event)
+			if err != nil {
+				return backendError(err, "failed to create event", "無法建立活動").Result(), nil
+			}
+
+			return renderResult(created, output)
+		}
+}
+
+// hostedEvent loads an event and checks that user is its host.
+func hostedEvent(ctx context.Context, store EventStore, eventID string, user *UserAgg, action string) (*EventDetail, *ToolError) {
+	event, err := store.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, eventLookupError(eventID, err)
+	}
+	if event.CreatedByUser == nil || event.CreatedByUser.ID != user.ID {
+		return nil, onlyHost(eventID, action)
+	}
+	if event.Status == EventStatusCancelled {
+		return nil, eventRefusal(action, eventID, ErrEventCancelled)
+	}
+	return event, nil
+}
+
+// updateEventArgs are the arguments of update_event. The event fields are
+// bound separately, over the current values of the event.
+type updateEventArgs struct {
+	eventIDArgs
+	eventFieldArgs
+}
+
+// rescheduleArgs are the update_event arguments that move an event in time.
+// An update giving any of them must leave the event upcoming, as create_event
+// does.
+var rescheduleArgs = []string{"events_date", "events_date_end", "events_deadline"}
+
+func UpdateEvent(store EventStore, writer EventWriter, users UserStore, bounds Bounds, now func() time.Time) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("update_event",
+			mcp.WithDescription("Change an OOSA event hosted by the authenticated user, e.g. move it to another day. Only the fields given are changed; the result must pass the same checks as create_event, so a new date or deadline must not be in the past"),
+			WithArgs(updateEventArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// The arguments are checked here, and bound for good once the
+			// event is loaded.
+			var args updateEventArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			fields := 0
+			for name := range request.Params.Arguments {
+				if name != "events_id" && name != "format" && name != "timezone" {
+					fields++
+				}
+			}
+			if fields == 0 {
+				return invalidArgument(errors.New("no fields to update")).Result(), nil
+			}
+
+			user, err := callerUser(ctx, users)
+			if err != nil {
+				return callerError(err).Result(), nil
+			}
+
+			event, toolErr := hostedEvent(ctx, store, args.EventID, user, "update")
+			if toolErr != nil {
+				return toolErr.Result(), nil
+			}
+
+			args.eventFieldArgs = eventFieldArgsOf(*event)
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			if err := args.apply(event); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			validate := validateEvent
+			for _, name := range rescheduleArgs {
+				if _, ok := request.Params.Arguments[name]; ok {
+					validate = func(e Event, bounds Bounds) error { return validateNewEvent(e, bounds, now()) }
+				}
+			}
+			if err := validate(event.Event, bounds); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			normalizeEventTimes(event)
+
+			updated, err := writer.UpdateEvent(ctx, *event)
+			return eventChangeResult("update", args.EventID, updated, err, output)
+		}
+}
+
+// cancelEventArgs are the arguments of cancel_event.
+type cancelEventArgs struct {
+	eventIDArgs
+	Reason string `arg:"reason,required" desc:"Why the event is called off, e.g. 豪雨特報，為安全起見取消"`
+}
+
+func CancelEvent(store EventStore, writer EventWriter, users UserStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("cancel_event",
+			mcp.WithDescription("Call off an OOSA event hosted by the authenticated user. The reason is shown to participants on the event"),
+			WithArgs(cancelEventArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args cancelEventArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			user, err := callerUser(ctx, users)
+			if err != nil {
+				return callerError(err).Result(), nil
+			}
+
+			if _, toolErr := hostedEvent(ctx, store, args.EventID, user, "cancel"); toolErr != nil {
+				return toolErr.Result(), nil
 			}
 
-			return mcp.NewToolResultText(string(r)), nil
+			cancelled, err := writer.CancelEvent(ctx, args.EventID, strings.TrimSpace(args.Reason))
+			return eventChangeResult("cancel", args.EventID, cancelled, err, output)
 		}
 }
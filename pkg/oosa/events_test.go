@@ -0,0 +1,214 @@
+package oosa
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventToolSchemas(t *testing.T) {
+	tests := []struct {
+		tool     func() []string
+		required []string
+	}{
+		{func() []string {
+			tool, _ := CreateEvent(nil, nil, DefaultBounds, time.Now)
+			return tool.InputSchema.Required
+		}, []string{"events_date", "events_date_end", "events_deadline", "events_lat", "events_lng", "events_name", "events_participant_limit", "events_place", "events_type"}},
+		{func() []string {
+			tool, _ := UpdateEvent(nil, nil, nil, DefaultBounds, time.Now)
+			return tool.InputSchema.Required
+		}, []string{"events_id"}},
+	}
+	for _, tt := range tests {
+		required := tt.tool()
+		slices.Sort(required)
+		if !slices.Equal(required, tt.required) {
+			t.Errorf("required %v, want %v", required, tt.required)
+		}
+	}
+
+	create, _ := CreateEvent(nil, nil, DefaultBounds, time.Now)
+	update, _ := UpdateEvent(nil, nil, nil, DefaultBounds, time.Now)
+	for name := range create.InputSchema.Properties {
+		if _, ok := update.InputSchema.Properties[name]; !ok {
+			t.Errorf("update_event does not take %s", name)
+		}
+	}
+}
+
+func TestCreateEventArgs(t *testing.T) {
+	client := newTestClient(t, NewMockClient()).as("user1")
+
+	args := newEventArgs("草山夜騎")
+	args["events_payment_required"] = true
+	args["events_payment_fee"] = 300.0
+	args["events_description"] = "自備車燈"
+	var created EventDetail
+	client.callTool("create_event", args, &created)
+
+	if created.Name != "草山夜騎" || created.Place != "陽明山" || created.Type != "健行" {
+		t.Errorf("created %+v", created.Event)
+	}
+	if created.PaymentRequired != 1 || created.PaymentFee != 300 || created.Description != "自備車燈" {
+		t.Errorf("payment %d/%v, description %q", created.PaymentRequired, created.PaymentFee, created.Description)
+	}
+	if created.MeetingPointName != "陽明山" || created.MeetingPointLat != 25.155 || created.MeetingPointLng != 121.548 {
+		t.Errorf("meeting point %q %v,%v, want the venue", created.MeetingPointName, created.MeetingPointLat, created.MeetingPointLng)
+	}
+
+	for name, value := range map[string]any{
+		"events_participant_limit": "10",
+		"events_lat":               91.0,
+		"events_date":              "tomorrow",
+		"events_payment_required":  "yes",
+	} {
+		args := newEventArgs("草山夜騎")
+		args[name] = value
+		if text, isError := client.tryTool("create_event", args); !isError || !strings.Contains(text, name) {
+			t.Errorf("%s=%v: %s", name, value, text)
+		}
+	}
+
+	for _, name := range []string{"events_place", "events_type"} {
+		args := newEventArgs("草山夜騎")
+		delete(args, name)
+		if text, isError := client.tryTool("create_event", args); !isError || !strings.Contains(text, name) {
+			t.Errorf("without %s: %s", name, text)
+		}
+	}
+}
+
+func TestUpdateEventKeepsOtherFields(t *testing.T) {
+	backend := NewMockClient()
+	now := mustParseTime("2025-04-01T12:00:00+08:00").Time
+	client := newTestClient(t, backend, WithClock(func() time.Time { return now })).as("user1")
+	before, err := backend.GetEvent(context.Background(), "event1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var updated EventDetail
+	client.callTool("update_event", map[string]any{
+		"events_id":               "event1",
+		"events_date_end":         "2025-04-11T21:00:00Z",
+		"events_payment_required": true,
+		"events_payment_fee":      100.0,
+		"format":                  "json",
+	}, &updated)
+
+	want := *before
+	want.DateEnd = mustParseTime("2025-04-11T21:00:00Z")
+	want.PaymentRequired, want.PaymentFee = 1, 100
+	if updated.Name != want.Name || updated.Type != want.Type || updated.Photo != want.Photo ||
+		updated.MeetingPointName != want.MeetingPointName || updated.ParticipantLimit != want.ParticipantLimit {
+		t.Errorf("untouched fields changed: %+v", updated.Event)
+	}
+	if !updated.Date.Equal(want.Date.Time) || !updated.Deadline.Equal(want.Deadline.Time) || !updated.DateEnd.Equal(want.DateEnd.Time) {
+		t.Errorf("times %v %v %v", updated.Date, updated.DateEnd, updated.Deadline)
+	}
+	if updated.PaymentRequired != 1 || updated.PaymentFee != 100 {
+		t.Errorf("payment %d/%v", updated.PaymentRequired, updated.PaymentFee)
+	}
+
+	client.callTool("update_event", map[string]any{"events_id": "event1", "events_type": "food", "format": "json"}, &updated)
+	if updated.Type != "美食" {
+		t.Errorf("type %q, want 美食", updated.Type)
+	}
+
+	if text, isError := client.tryTool("update_event", map[string]any{"events_name": "x"}); !isError || !strings.Contains(text, "events_id") {
+		t.Errorf("without events_id: %s", text)
+	}
+	if text, isError := client.tryTool("update_event", map[string]any{"events_id": "event1", "events_lat": "north"}); !isError || !strings.Contains(text, "events_lat") {
+		t.Errorf("string latitude: %s", text)
+	}
+}
+
+func TestCreateEventRejectsPastTimes(t *testing.T) {
+	now := mustParseTime("2025-04-01T12:00:00+08:00").Time
+	client := newTestClient(t, NewMockClient(), WithClock(func() time.Time { return now })).as("user1")
+	args := func(start, deadline time.Time) map[string]any {
+		args := newEventArgs("草山夜騎")
+		args["events_date"] = start.Format(time.RFC3339)
+		args["events_date_end"] = start.Add(3 * time.Hour).Format(time.RFC3339)
+		args["events_deadline"] = deadline.Format(time.RFC3339)
+		return args
+	}
+
+	tests := []struct {
+		name            string
+		start, deadline time.Time
+		problems        []string
+	}{
+		{"upcoming", now.Add(48 * time.Hour), now.Add(24 * time.Hour), nil},
+		{"registration closed", now.Add(48 * time.Hour), now.Add(-time.Hour), []string{"events_deadline"}},
+		{"deadline now", now.Add(48 * time.Hour), now, []string{"events_deadline"}},
+		{"started", now.Add(-time.Hour), now.Add(-2 * time.Hour), []string{"events_date", "events_deadline"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := client.tryTool("create_event", args(tt.start, tt.deadline))
+			if isError != (tt.problems != nil) {
+				t.Fatalf("isError %v: %s", isError, text)
+			}
+			for _, name := range tt.problems {
+				if !strings.Contains(text, name+" ") || !strings.Contains(text, "in the past") {
+					t.Errorf("%s not reported: %s", name, text)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateEventRules(t *testing.T) {
+	// event1 of user1 is under way; the deadline of event4 is two days off.
+	now := mustParseTime("2025-04-11T18:00:00Z").Time
+	client := newTestClient(t, NewMockClient(), WithClock(func() time.Time { return now }))
+	host := client.as("user1")
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		problems []string
+	}{
+		{"details of a past event", map[string]any{"events_id": "event1", "events_description": "已結束"}, nil},
+		{"end of a started event", map[string]any{"events_id": "event1", "events_date_end": "2025-04-11T21:00:00Z"}, []string{"events_date", "events_deadline"}},
+		{"deadline moved into the past", map[string]any{"events_id": "event4", "events_deadline": "2025-04-11T00:00:00Z"}, []string{"events_deadline"}},
+		{"date moved into the past", map[string]any{"events_id": "event4", "events_date": "2025-04-11T17:00:00Z", "events_deadline": "2025-04-11T16:00:00Z"}, []string{"events_date", "events_deadline"}},
+		{"deadline moved later", map[string]any{"events_id": "event4", "events_deadline": "2025-04-14T12:00:00Z"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := host.tryTool("update_event", tt.args)
+			if isError != (tt.problems != nil) {
+				t.Fatalf("isError %v: %s", isError, text)
+			}
+			for _, name := range tt.problems {
+				if !strings.Contains(text, name+" ") || !strings.Contains(text, "in the past") {
+					t.Errorf("%s not reported: %s", name, text)
+				}
+			}
+		})
+	}
+
+	text, isError := client.as("user2").tryTool("update_event", map[string]any{"events_id": "event4", "events_name": "搶來的活動"})
+	if !isError || !strings.Contains(text, `"code":"permission_denied"`) {
+		t.Errorf("update by another user: %s", text)
+	}
+
+	var cancelled EventDetail
+	host.callTool("cancel_event", map[string]any{"events_id": "event4", "reason": "豪雨特報，為安全起見取消", "format": "json"}, &cancelled)
+	text, isError = host.tryTool("update_event", map[string]any{"events_id": "event4", "events_name": "改期再辦"})
+	if !isError || !strings.Contains(text, `"code":"failed_precondition"`) || !strings.Contains(text, "活動已取消") {
+		t.Errorf("update of a cancelled event: %s", text)
+	}
+
+	// Participants see why the event is off.
+	var seen EventDetail
+	client.as("user2").callTool("get_event", map[string]any{"events_id": "event4", "format": "json"}, &seen)
+	if seen.Status != EventStatusCancelled || seen.CancelReason != "豪雨特報，為安全起見取消" || seen.Name != cancelled.Name {
+		t.Errorf("participants see %s %q", seen.Status, seen.CancelReason)
+	}
+}
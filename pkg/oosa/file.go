@@ -0,0 +1,80 @@
+package oosa
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// fileFixtures is the layout of a fixture file read by FileClient:
+//
+//	{"users": [...], "events": [...], "ideas": [...]}
+//
+// Events may also carry the detail-only "events_description" and
+// "events_participant_ids" (user IDs in the order they joined).
+type fileFixtures struct {
+	Users  []UserAgg   `json:"users"`
+	Events []fileEvent `json:"events"`
+	Ideas  []Idea      `json:"ideas"`
+}
+
+type fileEvent struct {
+	Event
+	Description    string   `json:"events_description"`
+	ParticipantIDs []string `json:"events_participant_ids"`
+}
+
+// FileClient serves OOSA data from a JSON fixture file, so the same binary can
+// run against hand-written dev data. Changes are kept in memory only and are
+// not written back to the file.
+type FileClient struct {
+	*memoryStore
+}
+
+// NewFileClient loads the fixture file at path.
+func NewFileClient(path string) (*FileClient, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+
+	var fixtures fileFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file %s: %w", path, err)
+	}
+
+	users := make(map[string]UserAgg, len(fixtures.Users))
+	for _, u := range fixtures.Users {
+		users[u.ID] = u
+	}
+
+	events := make([]Event, 0, len(fixtures.Events))
+	for _, e := range fixtures.Events {
+		events = append(events, e.Event)
+	}
+
+	store := newMemoryStore(fixtures.Users, events, fixtures.Ideas)
+	for _, e := range fixtures.Events {
+		if e.Description != "" {
+			store.descriptions[e.ID] = e.Description
+		}
+		if len(e.ParticipantIDs) == 0 {
+			continue
+		}
+		list := make([]UserAgg, 0, len(e.ParticipantIDs))
+		for _, id := range e.ParticipantIDs {
+			u, ok := users[id]
+			if !ok {
+				return nil, fmt.Errorf("event %s in %s: unknown participant %s", e.ID, path, id)
+			}
+			list = append(list, u)
+		}
+		store.participants[e.ID] = list
+	}
+
+	return &FileClient{memoryStore: store}, nil
+}
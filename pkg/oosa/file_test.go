@@ -0,0 +1,115 @@
+package oosa
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const fixturesPath = "testdata/fixtures.json"
+
+func TestFileClient(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewFileClient(fixturesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := client.GetEvents(ctx, EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := eventIDs(events); !slices.Equal(ids, []string{"wulai", "tamsui"}) {
+		t.Fatalf("events %v", ids)
+	}
+	// A fixture without a status is an active event.
+	if events[1].Status != EventStatusActive || events[1].Participants != nil {
+		t.Errorf("tamsui: status %q, participants %+v", events[1].Status, events[1].Participants)
+	}
+
+	wulai, err := client.GetEvent(ctx, "wulai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wulai.Description != "搭公車到烏來老街，午餐後泡湯。" || wulai.PaymentFee != 450 || !wulai.Deadline.Equal(mustParseTime("2025-04-17T15:59:59Z").Time) {
+		t.Errorf("wulai %+v", wulai)
+	}
+	var joined []string
+	for _, u := range wulai.ParticipantList {
+		joined = append(joined, u.Name)
+	}
+	if !slices.Equal(joined, []string{"阿美", "阿輝"}) {
+		t.Errorf("participants %v, want them in the order of events_participant_ids", joined)
+	}
+	if wulai.Host == nil || wulai.Host.Name != "阿美" || wulai.Host.EventsHosted != 1 {
+		t.Errorf("host %+v", wulai.Host)
+	}
+
+	ideas, err := client.GetIdeas(ctx, IdeaFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ideas) != 1 || ideas[0].ID != "pingxi" || ideas[0].CreatedByUser.Name != "阿美" {
+		t.Errorf("ideas %+v", ideas)
+	}
+	if user, err := client.GetUser(ctx, "ahui"); err != nil || user.Name != "阿輝" {
+		t.Errorf("user %+v, %v", user, err)
+	}
+}
+
+func TestFileClientKeepsFileAsIs(t *testing.T) {
+	original, err := os.ReadFile(fixturesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := os.WriteFile(path, original, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewFileClient(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, err := client.CancelEvent(context.Background(), "tamsui", "颱風")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != EventStatusCancelled {
+		t.Errorf("status %q", cancelled.Status)
+	}
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("fixture file changed: %v", err)
+	}
+}
+
+func TestFileClientRejects(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "file path is required"},
+		{filepath.Join(dir, "missing.json"), "failed to read fixture file"},
+		{write("broken.json", `{"events": [`), "failed to parse fixture file"},
+		{write("wrong.json", `{"events": {"events_id": "a"}}`), "failed to parse fixture file"},
+		{write("stranger.json", `{"users": [], "events": [{"events_id": "a", "events_participant_ids": ["nobody"]}]}`), "event a in " + dir},
+	}
+	for _, tt := range tests {
+		if _, err := NewFileClient(tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v, want %q", tt.path, err, tt.want)
+		}
+	}
+}
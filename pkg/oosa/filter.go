@@ -0,0 +1,194 @@
+package oosa
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// taipei is the zone OOSA events take place in. Taiwan has no daylight
+// saving time, so a fixed offset is exact and needs no tzdata.
+var taipei = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// dateOnlyLayout is the layout accepted for date-only period bounds.
+const dateOnlyLayout = "2006-01-02"
+
+// PeriodFilter narrows a listing by when its items take place.
+type PeriodFilter struct {
+	// Past keeps only items that have ended when true, and only items that
+	// have not ended yet (upcoming or ongoing) when false. Nil keeps both.
+	Past *bool
+	// Begin and End bound the period an item must overlap, both inclusive.
+	// Either may be nil for an open-ended period.
+	Begin *time.Time
+	End   *time.Time
+}
+
+// EventFilter narrows the events returned by an EventStore.
+type EventFilter struct {
+	PeriodFilter
+	// Category keeps only the events of this category ID when set.
+	Category CategoryID
+}
+
+// IdeaFilter narrows the ideas returned by an IdeaStore.
+type IdeaFilter struct {
+	PeriodFilter
+}
+
+// ParseEventFilter builds an EventFilter from the raw get_events arguments.
+// See parsePeriodFilter for the accepted formats.
+func ParseEventFilter(past, begin, end string, loc *time.Location) (EventFilter, error) {
+	period, err := parsePeriodFilter("event", past, begin, end, loc)
+	if err != nil {
+		return EventFilter{}, err
+	}
+	return EventFilter{PeriodFilter: period}, nil
+}
+
+// ParseIdeaFilter builds an IdeaFilter from the raw get_ideas arguments.
+// See parsePeriodFilter for the accepted formats.
+func ParseIdeaFilter(past, begin, end string, loc *time.Location) (IdeaFilter, error) {
+	period, err := parsePeriodFilter("idea", past, begin, end, loc)
+	if err != nil {
+		return IdeaFilter{}, err
+	}
+	return IdeaFilter{PeriodFilter: period}, nil
+}
+
+// parsePeriodFilter builds a PeriodFilter from the <prefix>_past,
+// <prefix>_period_begin and <prefix>_period_end arguments.
+//
+// past accepts true/false, yes/no or 1/0. begin and end accept RFC3339
+// timestamps or dates (2006-01-02); dates are taken in loc, with a date-only
+// end covering that whole day.
+func parsePeriodFilter(prefix, past, begin, end string, loc *time.Location) (PeriodFilter, error) {
+	var filter PeriodFilter
+
+	if past != "" {
+		p, err := parseBool(past)
+		if err != nil {
+			return PeriodFilter{}, fmt.Errorf("invalid %s_past %q: %w", prefix, past, err)
+		}
+		filter.Past = &p
+	}
+
+	if begin != "" {
+		t, err := parsePeriodBound(begin, false, loc)
+		if err != nil {
+			return PeriodFilter{}, fmt.Errorf("invalid %s_period_begin %q: %w", prefix, begin, err)
+		}
+		filter.Begin = &t
+	}
+
+	if end != "" {
+		t, err := parsePeriodBound(end, true, loc)
+		if err != nil {
+			return PeriodFilter{}, fmt.Errorf("invalid %s_period_end %q: %w", prefix, end, err)
+		}
+		filter.End = &t
+	}
+
+	if filter.Begin != nil && filter.End != nil && filter.End.Before(*filter.Begin) {
+		return PeriodFilter{}, fmt.Errorf("%s_period_end %q is before %s_period_begin %q", prefix, end, prefix, begin)
+	}
+
+	return filter, nil
+}
+
+// parseBool accepts the usual spellings of a boolean flag.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected true or false")
+	}
+}
+
+// parsePeriodBound parses an RFC3339 timestamp or a date in loc. A date used
+// as the end of a period is moved to the last instant of that day.
+func parsePeriodBound(s string, end bool, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(dateOnlyLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 timestamp (2025-04-11T16:00:00Z) or a date (2025-04-11)")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// IsZero reports whether the filter keeps everything.
+func (f PeriodFilter) IsZero() bool {
+	return f.Past == nil && f.Begin == nil && f.End == nil
+}
+
+// matchPeriod reports whether an item running from start to end passes the
+// filter at the given time. An item without a start never matches a filter
+// that needs one; a missing end is taken to equal the start.
+func (f PeriodFilter) matchPeriod(from, to Time, now time.Time) bool {
+	if f.IsZero() {
+		return true
+	}
+
+	if from.IsZero() {
+		return false
+	}
+	if to.IsZero() {
+		to = from
+	}
+
+	if f.Past != nil && *f.Past != to.Before(now) {
+		return false
+	}
+	if f.Begin != nil && to.Before(*f.Begin) {
+		return false
+	}
+	if f.End != nil && from.After(*f.End) {
+		return false
+	}
+	return true
+}
+
+// Match reports whether e passes the filter at the given time.
+func (f EventFilter) Match(e Event, now time.Time) bool {
+	if f.Category != "" && EventCategory(e.Type).ID != string(f.Category) {
+		return false
+	}
+	return f.matchPeriod(e.Date, e.DateEnd, now)
+}
+
+// Match reports whether i passes the filter at the given time.
+func (f IdeaFilter) Match(i Idea, now time.Time) bool {
+	return f.matchPeriod(i.Date, i.DateEnd, now)
+}
+
+// filterEvents returns the events matching f, keeping their order.
+func filterEvents(events []Event, f EventFilter, now time.Time) []Event {
+	filtered := make([]Event, 0, len(events))
+	for _, e := range events {
+		if f.Match(e, now) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// filterIdeas returns the ideas matching f, keeping their order.
+func filterIdeas(ideas []Idea, f IdeaFilter, now time.Time) []Idea {
+	filtered := make([]Idea, 0, len(ideas))
+	for _, i := range ideas {
+		if f.Match(i, now) {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
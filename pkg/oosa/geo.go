@@ -0,0 +1,339 @@
+package oosa
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// kmPerDegreeLat is the length of one degree of latitude.
+	kmPerDegreeLat = 111.32
+	// geoCellDegrees is the size of a GeoIndex grid cell, about 5.5 km north-south.
+	geoCellDegrees = 0.05
+	// geoLngCells is the number of grid cells around a circle of latitude.
+	geoLngCells = 7200
+)
+
+// haversineKm returns the great-circle distance between two points in kilometres.
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// GeoMatch selects which location of an event a geo search compares against.
+type GeoMatch string
+
+const (
+	// GeoMatchVenue uses the event place (Lat/Lng)
+	GeoMatchVenue GeoMatch = "venue"
+	// GeoMatchMeetingPoint uses the meeting point (MeetingPointLat/MeetingPointLng)
+	GeoMatchMeetingPoint GeoMatch = "meeting_point"
+	// GeoMatchEither uses whichever of the two is closer
+	GeoMatchEither GeoMatch = "either"
+)
+
+// geoCell identifies a cell of the GeoIndex grid.
+type geoCell struct {
+	lat, lng int
+}
+
+// normalizeLng maps a longitude into [-180, 180). Longitudes already in
+// range are returned as they are, as the arithmetic would round them.
+func normalizeLng(lng float64) float64 {
+	if lng >= -180 && lng < 180 {
+		return lng
+	}
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
+
+// wrapLngCell maps a column of the grid into [-geoLngCells/2, geoLngCells/2),
+// so that the columns either side of ±180° are neighbours.
+func wrapLngCell(x int) int {
+	x = (x + geoLngCells/2) % geoLngCells
+	if x < 0 {
+		x += geoLngCells
+	}
+	return x - geoLngCells/2
+}
+
+func cellOf(lat, lng float64) geoCell {
+	return geoCell{
+		lat: int(math.Floor(lat / geoCellDegrees)),
+		lng: wrapLngCell(int(math.Floor(lng / geoCellDegrees))),
+	}
+}
+
+// geoPoint is one location of an event stored in the grid.
+type geoPoint struct {
+	eventID string
+	kind    GeoMatch
+	lat     float64
+	lng     float64
+}
+
+// geoEntry is an event held by the GeoIndex.
+type geoEntry struct {
+	event  Event
+	points []geoPoint
+	// gen is the generation of the index the event was last written in.
+	gen uint64
+}
+
+// GeoIndex is a spatial grid over event venues and meeting points, so a
+// radius search only measures the events in nearby cells. Like SearchIndex
+// it is kept current by Upsert and an IndexRefresher.
+type GeoIndex struct {
+	mu     sync.RWMutex
+	events map[string]*geoEntry
+	cells  map[geoCell][]geoPoint
+	// gen counts the calls to Upsert.
+	gen uint64
+}
+
+// NewGeoIndex creates an empty GeoIndex.
+func NewGeoIndex() *GeoIndex {
+	return &GeoIndex{
+		events: make(map[string]*geoEntry),
+		cells:  make(map[geoCell][]geoPoint),
+	}
+}
+
+// eventPoints returns the locations of e, with longitudes normalised. Points
+// at 0,0 are treated as unset.
+func eventPoints(e Event) []geoPoint {
+	var points []geoPoint
+	if e.Lat != 0 || e.Lng != 0 {
+		points = append(points, geoPoint{eventID: e.ID, kind: GeoMatchVenue, lat: e.Lat, lng: normalizeLng(e.Lng)})
+	}
+	if e.MeetingPointLat != 0 || e.MeetingPointLng != 0 {
+		points = append(points, geoPoint{eventID: e.ID, kind: GeoMatchMeetingPoint, lat: e.MeetingPointLat, lng: normalizeLng(e.MeetingPointLng)})
+	}
+	return points
+}
+
+func samePoints(a, b []geoPoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Upsert adds an event to the index or refreshes it.
+func (ix *GeoIndex) Upsert(e Event) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.gen++
+	ix.upsert(e, ix.gen)
+}
+
+// Remove drops an event from the index.
+func (ix *GeoIndex) Remove(eventID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(eventID)
+}
+
+// mark and sync let an IndexRefresher keep the index current, see eventIndex.
+func (ix *GeoIndex) mark() uint64 {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.gen
+}
+
+func (ix *GeoIndex) sync(events []Event, mark uint64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	current := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		current[e.ID] = struct{}{}
+		if entry, ok := ix.events[e.ID]; !ok || entry.gen <= mark {
+			ix.upsert(e, mark)
+		}
+	}
+	for id, entry := range ix.events {
+		if _, ok := current[id]; !ok && entry.gen <= mark {
+			ix.remove(id)
+		}
+	}
+}
+
+// upsert indexes e as written in generation gen. Callers must hold ix.mu.
+func (ix *GeoIndex) upsert(e Event, gen uint64) {
+	points := eventPoints(e)
+	if entry, ok := ix.events[e.ID]; ok && samePoints(entry.points, points) {
+		entry.event = e
+		entry.gen = gen
+		return
+	}
+	ix.remove(e.ID)
+
+	for _, p := range points {
+		cell := cellOf(p.lat, p.lng)
+		ix.cells[cell] = append(ix.cells[cell], p)
+	}
+	ix.events[e.ID] = &geoEntry{event: e, points: points, gen: gen}
+}
+
+// remove drops an event. Callers must hold ix.mu.
+func (ix *GeoIndex) remove(eventID string) {
+	entry, ok := ix.events[eventID]
+	if !ok {
+		return
+	}
+	for _, p := range entry.points {
+		cell := cellOf(p.lat, p.lng)
+		kept := ix.cells[cell][:0]
+		for _, q := range ix.cells[cell] {
+			if q.eventID != eventID {
+				kept = append(kept, q)
+			}
+		}
+		if len(kept) == 0 {
+			delete(ix.cells, cell)
+		} else {
+			ix.cells[cell] = kept
+		}
+	}
+	delete(ix.events, eventID)
+}
+
+// NearbyEvent is an event found by a radius search.
+type NearbyEvent struct {
+	DistanceKm float64  `json:"distance_km"`
+	MatchedOn  GeoMatch `json:"matched_on"`
+	Event      Event    `json:"event"`
+}
+
+// Nearby returns the events within radiusKm of lat/lng, closest first.
+func (ix *GeoIndex) Nearby(lat, lng, radiusKm float64, match GeoMatch) []NearbyEvent {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Cover the bounding box of the circle with grid cells. Longitude degrees
+	// shrink towards the poles, so the box widens with latitude. Columns are
+	// counted unwrapped and wrapped on lookup, so a box across ±180° takes
+	// the cells on both sides.
+	lng = normalizeLng(lng)
+	dLat := radiusKm / kmPerDegreeLat
+	dLng := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 1e-6 {
+		dLng = math.Min(180, radiusKm/(kmPerDegreeLat*cos))
+	}
+	loLat := int(math.Floor((lat - dLat) / geoCellDegrees))
+	hiLat := int(math.Floor((lat + dLat) / geoCellDegrees))
+	loLng := int(math.Floor((lng - dLng) / geoCellDegrees))
+	hiLng := min(int(math.Floor((lng+dLng)/geoCellDegrees)), loLng+geoLngCells-1)
+
+	best := make(map[string]NearbyEvent)
+	for cy := loLat; cy <= hiLat; cy++ {
+		for cx := loLng; cx <= hiLng; cx++ {
+			for _, p := range ix.cells[geoCell{lat: cy, lng: wrapLngCell(cx)}] {
+				if match != GeoMatchEither && p.kind != match {
+					continue
+				}
+				d := haversineKm(lat, lng, p.lat, p.lng)
+				if d > radiusKm {
+					continue
+				}
+				if prev, ok := best[p.eventID]; ok && prev.DistanceKm <= d {
+					continue
+				}
+				best[p.eventID] = NearbyEvent{
+					DistanceKm: d,
+					MatchedOn:  p.kind,
+					Event:      ix.events[p.eventID].event,
+				}
+			}
+		}
+	}
+
+	results := make([]NearbyEvent, 0, len(best))
+	for _, r := range best {
+		r.DistanceKm = math.Round(r.DistanceKm*1000) / 1000
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceKm != results[j].DistanceKm {
+			return results[i].DistanceKm < results[j].DistanceKm
+		}
+		return results[i].Event.ID < results[j].Event.ID
+	})
+	return results
+}
+
+// NearbyEventsResult is the response of find_events_nearby.
+type NearbyEventsResult struct {
+	Results    []NearbyEvent `json:"results"`
+	TotalCount int           `json:"total_count"`
+}
+
+// findEventsNearbyArgs are the arguments of find_events_nearby.
+type findEventsNearbyArgs struct {
+	Lat      float64 `arg:"lat,required,min=-90,max=90" desc:"Latitude of the search center, e.g. 25.0330"`
+	Lng      float64 `arg:"lng,required,min=-180,max=180" desc:"Longitude of the search center, e.g. 121.5654"`
+	RadiusKm float64 `arg:"radius_km,min=0,max=200,default=5" desc:"Search radius in km"`
+	MatchOn  string  `arg:"match_on,enum=venue|meeting_point|either,default=venue" desc:"Which location to measure: the event venue, its meeting point, or whichever is closer"`
+	Limit    int     `arg:"limit,min=1,max=100,default=20" desc:"Maximum number of results"`
+}
+
+// FindEventsNearby searches index, which refresher keeps current. Events
+// that are cancelled or have ended by now are left out.
+func FindEventsNearby(index *GeoIndex, refresher *IndexRefresher, now func() time.Time) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("find_events_nearby",
+			mcp.WithDescription("Find upcoming and ongoing OOSA events within a radius of a coordinate, closest first, with the distance in km. Cancelled and past events are left out"),
+			WithArgs(findEventsNearbyArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args findEventsNearbyArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			if err := refresher.Refresh(ctx); err != nil {
+				return backendError(err, "failed to get events", "無法取得活動列表").Result(), nil
+			}
+
+			upcoming := false
+			current := EventFilter{PeriodFilter: PeriodFilter{Past: &upcoming}}
+			at := now()
+			results := slices.DeleteFunc(index.Nearby(args.Lat, args.Lng, args.RadiusKm, GeoMatch(args.MatchOn)), func(r NearbyEvent) bool {
+				return r.Event.Status == EventStatusCancelled || !current.Match(r.Event, at)
+			})
+			total := len(results)
+			if len(results) > args.Limit {
+				results = results[:args.Limit]
+			}
+
+			return renderResult(NearbyEventsResult{
+				Results:    results,
+				TotalCount: total,
+			}, output)
+		}
+}
@@ -0,0 +1,156 @@
+package oosa
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func nearbyIDs(results []NearbyEvent) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Event.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestGeoIndexFollowsWrites(t *testing.T) {
+	backend := &countingBackend{Backend: NewMockClient()}
+	client := newTestClient(t, backend)
+	nearby := func(lat, lng float64) []string {
+		t.Helper()
+		var out NearbyEventsResult
+		client.callTool("find_events_nearby", map[string]any{"lat": lat, "lng": lng, "radius_km": 1.0, "format": "json"}, &out)
+		return nearbyIDs(out.Results)
+	}
+
+	var created EventDetail
+	client.as("user1").callTool("create_event", newEventArgs("擎天崗草原"), &created)
+	if ids := nearby(25.155, 121.548); !slices.Contains(ids, created.ID) {
+		t.Errorf("created event not found: %v", ids)
+	}
+
+	client.as("user1").callTool("update_event", map[string]any{"events_id": created.ID, "events_lat": 24.0, "events_lng": 121.6}, nil)
+	if ids := nearby(25.155, 121.548); slices.Contains(ids, created.ID) {
+		t.Errorf("event still found at its old place: %v", ids)
+	}
+	if ids := nearby(24.0, 121.6); !slices.Equal(ids, []string{created.ID}) {
+		t.Errorf("event not found at its new place: %v", ids)
+	}
+
+	if n := backend.scans.Load(); n != 1 {
+		t.Errorf("%d full scans, want 1", n)
+	}
+}
+
+func TestFindEventsNearbySeesOutsideChanges(t *testing.T) {
+	backend := newOutsideBackend()
+	clock := newTestClock()
+	client := newTestClient(t, backend, WithClock(clock.Now), WithIndexRefresh(time.Minute))
+	nearby := func(lat, lng float64) []string {
+		t.Helper()
+		var out NearbyEventsResult
+		client.callTool("find_events_nearby", map[string]any{"lat": lat, "lng": lng, "radius_km": 1.0, "format": "json"}, &out)
+		return nearbyIDs(out.Results)
+	}
+	start := NewTime(clock.Now().Add(7 * 24 * time.Hour))
+	at := func(name string, lat, lng float64) Event {
+		return Event{Name: name, Date: start, Lat: lat, Lng: lng, Type: "hiking"}
+	}
+
+	deleted := backend.insert(t, at("擎天崗草原", 25.155, 121.548))
+	moved := backend.insert(t, at("冷水坑", 25.155, 121.548))
+	cancelled := backend.insert(t, at("夢幻湖", 25.155, 121.548))
+	if _, err := backend.Backend.CancelEvent(context.Background(), cancelled.ID, "颱風"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, want := nearby(25.155, 121.548), nearbyIDs([]NearbyEvent{{Event: deleted.Event}, {Event: moved.Event}}); !slices.Equal(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+	// The fixtures around Xiangshan all took place in 2025.
+	if ids := nearby(25.0330, 121.5700); len(ids) != 0 {
+		t.Errorf("past events found: %v", ids)
+	}
+
+	backend.delete(deleted.ID)
+	moved.Lat, moved.Lng = 24.0, 121.6
+	if _, err := backend.Backend.UpdateEvent(context.Background(), *moved); err != nil {
+		t.Fatal(err)
+	}
+	added := backend.insert(t, at("小油坑", 25.155, 121.548))
+	clock.advance(time.Minute)
+	if ids := nearby(25.155, 121.548); !slices.Equal(ids, []string{added.ID}) {
+		t.Errorf("after the changes: %v, want %v", ids, []string{added.ID})
+	}
+	if ids := nearby(24.0, 121.6); !slices.Equal(ids, []string{moved.ID}) {
+		t.Errorf("moved event: %v, want %v", ids, []string{moved.ID})
+	}
+}
+
+func TestGeoIndexAntimeridian(t *testing.T) {
+	index := NewGeoIndex()
+	index.Upsert(Event{ID: "east", Lat: -16.5, Lng: 179.99})
+	index.Upsert(Event{ID: "west", Lat: -16.5, Lng: -179.99})
+	index.Upsert(Event{ID: "unwrapped", Lat: -16.5, Lng: 180.02})
+	index.Upsert(Event{ID: "far", Lat: -16.5, Lng: 179.5})
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     []string
+	}{
+		{"from the east", -16.5, 179.995, []string{"east", "unwrapped", "west"}},
+		{"from the west", -16.5, -179.995, []string{"east", "unwrapped", "west"}},
+		{"at 180", -16.5, 180, []string{"east", "unwrapped", "west"}},
+		{"at -180", -16.5, -180, []string{"east", "unwrapped", "west"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := index.Nearby(tt.lat, tt.lng, 5, GeoMatchVenue)
+			if ids := nearbyIDs(results); !slices.Equal(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+			for _, r := range results {
+				if r.DistanceKm > 5 {
+					t.Errorf("%s at %.3f km", r.Event.ID, r.DistanceKm)
+				}
+			}
+		})
+	}
+
+	// A moved event leaves the cell it was filed under by its raw longitude.
+	index.Upsert(Event{ID: "unwrapped", Lat: 10, Lng: 10})
+	if ids := nearbyIDs(index.Nearby(-16.5, 180, 5, GeoMatchVenue)); !slices.Equal(ids, []string{"east", "west"}) {
+		t.Errorf("after move: %v", ids)
+	}
+}
+
+func TestNormalizeLng(t *testing.T) {
+	for lng, want := range map[float64]float64{
+		121.54:   121.54,
+		-180:     -180,
+		180:      -180,
+		180.02:   -179.98,
+		-180.5:   179.5,
+		541.5:    -178.5,
+		121.4986: 121.4986,
+	} {
+		if got := normalizeLng(lng); math.Abs(got-want) > 1e-9 || (lng >= -180 && lng < 180 && got != lng) {
+			t.Errorf("normalizeLng(%v) = %v, want %v", lng, got, want)
+		}
+	}
+}
+
+func TestGeoIndexWideRadius(t *testing.T) {
+	index := NewGeoIndex()
+	index.Upsert(Event{ID: "pole", Lat: 89.99, Lng: -170})
+	index.Upsert(Event{ID: "near pole", Lat: 89.95, Lng: 10})
+
+	if ids := nearbyIDs(index.Nearby(89.99, 170, 10, GeoMatchVenue)); !slices.Equal(ids, []string{"near pole", "pole"}) {
+		t.Errorf("got %v", ids)
+	}
+}
@@ -0,0 +1,125 @@
+package oosa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// GeoJSONRoute is the feature kind of the line from an event's meeting point
+// to its venue. Points use the GeoMatch kinds venue and meeting_point.
+const GeoJSONRoute = "route"
+
+// GeoJSONGeometry is a GeoJSON (RFC 7946) Point or LineString. Coordinates
+// are [longitude, latitude].
+type GeoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// GeoJSONFeature is one location of an event. Properties hold the event's
+// fields plus "feature_kind", and "distance_km" on routes.
+type GeoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Geometry   GeoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+// GeoJSONEventsPage is a page of get_events as a FeatureCollection. The
+// pagination fields are foreign members, which GeoJSON readers ignore.
+type GeoJSONEventsPage struct {
+	Type       string           `json:"type"`
+	Features   []GeoJSONFeature `json:"features"`
+	TotalCount int              `json:"total_count"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+// eventProperties returns the fields of e keyed by their JSON names.
+func eventProperties(e Event) (map[string]any, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event %s: %w", e.ID, err)
+	}
+	var props map[string]any
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event %s: %w", e.ID, err)
+	}
+	return props, nil
+}
+
+// eventFeatures returns a Point for the venue and for the meeting point of e,
+// and a LineString joining them when they are at different places.
+func eventFeatures(e Event) ([]GeoJSONFeature, error) {
+	points := eventPoints(e)
+	if len(points) == 0 {
+		return nil, nil
+	}
+
+	newFeature := func(kind string, geometry GeoJSONGeometry) (GeoJSONFeature, error) {
+		props, err := eventProperties(e)
+		if err != nil {
+			return GeoJSONFeature{}, err
+		}
+		props["feature_kind"] = kind
+		return GeoJSONFeature{
+			Type:       "Feature",
+			ID:         e.ID + "/" + kind,
+			Geometry:   geometry,
+			Properties: props,
+		}, nil
+	}
+
+	features := make([]GeoJSONFeature, 0, len(points)+1)
+	for _, p := range points {
+		f, err := newFeature(string(p.kind), GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: []float64{p.lng, p.lat},
+		})
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+
+	if len(points) == 2 && (points[0].lat != points[1].lat || points[0].lng != points[1].lng) {
+		venue, meeting := points[0], points[1]
+		f, err := newFeature(GeoJSONRoute, GeoJSONGeometry{
+			Type: "LineString",
+			Coordinates: [][]float64{
+				{meeting.lng, meeting.lat},
+				{venue.lng, venue.lat},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		distance := haversineKm(meeting.lat, meeting.lng, venue.lat, venue.lng)
+		f.Properties["distance_km"] = math.Round(distance*100) / 100
+		features = append(features, f)
+	}
+
+	return features, nil
+}
+
+// geoJSONEventsPage converts a page of events to a FeatureCollection.
+// Events without coordinates have no features but still count in
+// TotalCount.
+func geoJSONEventsPage(page EventsPage) (GeoJSONEventsPage, error) {
+	features := []GeoJSONFeature{}
+	for _, e := range page.Events {
+		f, err := eventFeatures(e)
+		if err != nil {
+			return GeoJSONEventsPage{}, err
+		}
+		features = append(features, f...)
+	}
+	return GeoJSONEventsPage{
+		Type:       "FeatureCollection",
+		Features:   features,
+		TotalCount: page.TotalCount,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}, nil
+}
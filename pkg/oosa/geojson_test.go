@@ -0,0 +1,99 @@
+package oosa
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestEventFeatures(t *testing.T) {
+	tests := []struct {
+		event    string
+		venue    []float64
+		meeting  []float64
+		distance float64
+	}{
+		// The hot spring museum is a walk from Beitou station.
+		{"event3", []float64{121.5070, 25.1370}, []float64{121.4986, 25.1319}, 1.02},
+		// The bus goes up Yangmingshan from Jiantan station.
+		{"event5", []float64{121.5400, 25.1700}, []float64{121.5256, 25.0836}, 9.72},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			event := mockEvent(t, tt.event).Event
+			features, err := eventFeatures(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(features) != 3 {
+				t.Fatalf("%d features, want 2 points and a route", len(features))
+			}
+
+			venue, meeting, route := features[0], features[1], features[2]
+			if venue.ID != tt.event+"/venue" || venue.Geometry.Type != "Point" || !slices.Equal(venue.Geometry.Coordinates.([]float64), tt.venue) {
+				t.Errorf("venue %s %s %v", venue.ID, venue.Geometry.Type, venue.Geometry.Coordinates)
+			}
+			if meeting.ID != tt.event+"/meeting_point" || meeting.Geometry.Type != "Point" || !slices.Equal(meeting.Geometry.Coordinates.([]float64), tt.meeting) {
+				t.Errorf("meeting point %s %s %v", meeting.ID, meeting.Geometry.Type, meeting.Geometry.Coordinates)
+			}
+			line := route.Geometry.Coordinates.([][]float64)
+			if route.Geometry.Type != "LineString" || len(line) != 2 || !slices.Equal(line[0], tt.meeting) || !slices.Equal(line[1], tt.venue) {
+				t.Errorf("route %s %v, want from the meeting point to the venue", route.Geometry.Type, line)
+			}
+			if route.Properties["distance_km"] != tt.distance {
+				t.Errorf("distance_km %v, want %v", route.Properties["distance_km"], tt.distance)
+			}
+
+			for i, kind := range []string{"venue", "meeting_point", GeoJSONRoute} {
+				props := features[i].Properties
+				if props["feature_kind"] != kind || props["events_id"] != tt.event || props["events_name"] != event.Name {
+					t.Errorf("%s properties %v", kind, props)
+				}
+			}
+			if _, ok := venue.Properties["distance_km"]; ok {
+				t.Error("distance_km on a point")
+			}
+		})
+	}
+}
+
+func TestGeoJSONEventsPage(t *testing.T) {
+	// Xiangshan meets at the trailhead, so it has no route.
+	samePlace := mockEvent(t, "event1").Event
+	noPlace := mockEvent(t, "event2").Event
+	noPlace.ID = "online"
+	noPlace.Lat, noPlace.Lng, noPlace.MeetingPointLat, noPlace.MeetingPointLng = 0, 0, 0, 0
+
+	page, err := geoJSONEventsPage(EventsPage{
+		Events:     []Event{samePlace, noPlace},
+		TotalCount: 7,
+		NextCursor: "next",
+		HasMore:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, f := range page.Features {
+		ids = append(ids, f.ID)
+	}
+	if want := []string{"event1/venue", "event1/meeting_point"}; !slices.Equal(ids, want) {
+		t.Errorf("features %v, want %v", ids, want)
+	}
+	if page.Type != "FeatureCollection" || page.TotalCount != 7 || page.NextCursor != "next" || !page.HasMore {
+		t.Errorf("page %s %d %q %v", page.Type, page.TotalCount, page.NextCursor, page.HasMore)
+	}
+
+	// A page with nothing to draw is still a valid FeatureCollection.
+	page, err = geoJSONEventsPage(EventsPage{Events: []Event{noPlace}, TotalCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"FeatureCollection","features":[],"total_count":1,"has_more":false}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
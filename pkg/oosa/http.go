@@ -0,0 +1,337 @@
+package oosa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPConfig holds the settings needed to reach the OOSA REST API.
+type HTTPConfig struct {
+	BaseURL string
+	// AuthHeader is the header carrying AuthToken, "Authorization" by default.
+	AuthHeader string
+	AuthToken  string
+	Timeout    time.Duration
+}
+
+// APIError is returned by HTTPClient when the OOSA API answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	// Code is the "code" field of the response body, naming why a change
+	// was refused.
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("oosa api responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("oosa api responded with status %d: %s", e.StatusCode, e.Message)
+}
+
+// apiRefusals maps the codes the API gives refused changes to the
+// EventWriter errors they stand for.
+var apiRefusals = map[string]error{
+	"event_full":               ErrEventFull,
+	"registration_closed":      ErrRegistrationClosed,
+	"event_started":            ErrEventStarted,
+	"already_joined":           ErrAlreadyJoined,
+	"not_joined":               ErrNotJoined,
+	"event_cancelled":          ErrEventCancelled,
+	"limit_below_participants": ErrLimitBelowParticipants,
+}
+
+// Unwrap returns the EventWriter error behind a refused change, so that
+// errors.Is sees it, or nil. A refusal is a 409 or 422 response whose code,
+// or failing that whose message, names one of apiRefusals.
+func (e *APIError) Unwrap() error {
+	if e.StatusCode != http.StatusConflict && e.StatusCode != http.StatusUnprocessableEntity {
+		return nil
+	}
+	if err, ok := apiRefusals[e.Code]; ok {
+		return err
+	}
+	for _, err := range apiRefusals {
+		if strings.EqualFold(e.Message, err.Error()) {
+			return err
+		}
+	}
+	return nil
+}
+
+// HTTPClient proxies the OOSA web API. It expects:
+//
+//	GET /events?event_past=&event_period_begin=&event_period_end=  -> []Event (bounds as RFC3339)
+//	GET /events/{events_id}                                         -> EventDetail
+//	GET /ideas?idea_past=&idea_period_begin=&idea_period_end=      -> []Idea (bounds as RFC3339)
+//	GET /users/{user_id}                                            -> UserAgg
+//	POST /events (EventDetail)                                      -> EventDetail
+//	PUT /events/{events_id} (EventDetail)                           -> EventDetail
+//	POST /events/{events_id}/cancel ({"reason"})                    -> EventDetail
+//	POST /events/{events_id}/participants ({"user_id"})             -> EventDetail
+//	DELETE /events/{events_id}/participants/{user_id}               -> EventDetail
+//
+// Refused changes are answered with 409 Conflict or 422 Unprocessable Entity
+// and a JSON body whose "code" says why, e.g. {"code": "event_full"}; see
+// apiRefusals. They are passed on as an APIError wrapping the matching
+// EventWriter error.
+type HTTPClient struct {
+	baseURL    *url.URL
+	authHeader string
+	authToken  string
+	client     *http.Client
+}
+
+// NewHTTPClient creates an HTTPClient for the API at cfg.BaseURL.
+func NewHTTPClient(cfg HTTPConfig) (*HTTPClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("http base url is required")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid http base url: %w", err)
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid http base url %q: scheme must be http or https", cfg.BaseURL)
+	}
+	if cfg.AuthHeader == "" {
+		cfg.AuthHeader = "Authorization"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &HTTPClient{
+		baseURL:    baseURL,
+		authHeader: cfg.AuthHeader,
+		authToken:  cfg.AuthToken,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// get sends a GET request to path and decodes the JSON response into v.
+func (c *HTTPClient) get(ctx context.Context, path string, query url.Values, v any) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, v)
+}
+
+// apiPath joins path segments into an API path. Every segment is escaped, so
+// that an ID cannot point the request at another endpoint.
+func apiPath(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		if s == "." || s == ".." {
+			b.WriteString(strings.ReplaceAll(s, ".", "%2E"))
+			continue
+		}
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// do sends a request to path, which must be escaped, with body encoded as
+// JSON when not nil, and decodes the JSON response into v.
+func (c *HTTPClient) do(ctx context.Context, method, path string, query url.Values, body any, v any) error {
+	u, err := url.Parse(c.baseURL.String() + path)
+	if err != nil {
+		return fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authToken != "" {
+		req.Header.Set(c.authHeader, c.authToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call oosa api: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode oosa api response: %w", err)
+	}
+	return nil
+}
+
+// newAPIError builds an APIError from a non-2xx response, picking up the
+// "code" and the "message" or "error" field of a JSON body when there is one.
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &payload); err == nil {
+		switch {
+		case payload.Message != "":
+			message = payload.Message
+		case payload.Error != "":
+			message = payload.Error
+		}
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       payload.Code,
+		Message:    message,
+	}
+}
+
+// periodQuery builds the <prefix>_past and <prefix>_period_begin/end query
+// parameters shared by list endpoints.
+func periodQuery(prefix string, f PeriodFilter) url.Values {
+	query := url.Values{}
+	if f.Past != nil {
+		query.Set(prefix+"_past", strconv.FormatBool(*f.Past))
+	}
+	if f.Begin != nil {
+		query.Set(prefix+"_period_begin", f.Begin.Format(time.RFC3339))
+	}
+	if f.End != nil {
+		query.Set(prefix+"_period_end", f.End.Format(time.RFC3339))
+	}
+	return query
+}
+
+func (c *HTTPClient) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	var events []Event
+	if err := c.get(ctx, "/events", periodQuery("event", filter.PeriodFilter), &events); err != nil {
+		return nil, err
+	}
+
+	// Apply the filter again so the result does not depend on how strictly
+	// the API honours the query.
+	return filterEvents(events, filter, time.Now()), nil
+}
+
+func (c *HTTPClient) GetEvent(ctx context.Context, eventID string) (*EventDetail, error) {
+	var detail EventDetail
+	if err := c.get(ctx, apiPath("events", eventID), nil, &detail); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) CreateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	var detail EventDetail
+	if err := c.do(ctx, http.MethodPost, "/events", nil, event, &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) UpdateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	var detail EventDetail
+	if err := c.do(ctx, http.MethodPut, apiPath("events", event.ID), nil, event, &detail); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("event %s: %w", event.ID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) CancelEvent(ctx context.Context, eventID string, reason string) (*EventDetail, error) {
+	var detail EventDetail
+	body := map[string]string{"reason": reason}
+	if err := c.do(ctx, http.MethodPost, apiPath("events", eventID, "cancel"), nil, body, &detail); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) JoinEvent(ctx context.Context, eventID string, user UserAgg) (*EventDetail, error) {
+	var detail EventDetail
+	body := map[string]string{"user_id": user.ID}
+	if err := c.do(ctx, http.MethodPost, apiPath("events", eventID, "participants"), nil, body, &detail); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) LeaveEvent(ctx context.Context, eventID string, userID string) (*EventDetail, error) {
+	var detail EventDetail
+	if err := c.do(ctx, http.MethodDelete, apiPath("events", eventID, "participants", userID), nil, nil, &detail); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (c *HTTPClient) GetIdeas(ctx context.Context, filter IdeaFilter) ([]Idea, error) {
+	var ideas []Idea
+	if err := c.get(ctx, "/ideas", periodQuery("idea", filter.PeriodFilter), &ideas); err != nil {
+		return nil, err
+	}
+	return filterIdeas(ideas, filter, time.Now()), nil
+}
+
+func (c *HTTPClient) GetUser(ctx context.Context, userID string) (*UserAgg, error) {
+	var user UserAgg
+	if err := c.get(ctx, apiPath("users", userID), nil, &user); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *HTTPClient) Close(ctx context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,194 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apiRecorder is a fake OOSA API. It records the requests it gets and
+// answers every one with status and body.
+type apiRecorder struct {
+	status   int
+	body     string
+	requests []*http.Request
+	bodies   []string
+}
+
+func newAPIServer(t *testing.T, api *apiRecorder) *HTTPClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		api.requests = append(api.requests, r)
+		api.bodies = append(api.bodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(api.status)
+		_, _ = io.WriteString(w, api.body)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewHTTPClient(HTTPConfig{BaseURL: srv.URL + "/api/", AuthHeader: "X-Token", AuthToken: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestHTTPClientEscapesIDs(t *testing.T) {
+	ctx := context.Background()
+	calls := []struct {
+		name   string
+		call   func(c *HTTPClient, id string) error
+		method string
+		path   string
+	}{
+		{"get event", func(c *HTTPClient, id string) error { _, err := c.GetEvent(ctx, id); return err }, http.MethodGet, "/api/events/{id}"},
+		{"update event", func(c *HTTPClient, id string) error {
+			_, err := c.UpdateEvent(ctx, EventDetail{Event: Event{ID: id}})
+			return err
+		}, http.MethodPut, "/api/events/{id}"},
+		{"cancel event", func(c *HTTPClient, id string) error { _, err := c.CancelEvent(ctx, id, "rain"); return err }, http.MethodPost, "/api/events/{id}/cancel"},
+		{"join event", func(c *HTTPClient, id string) error { _, err := c.JoinEvent(ctx, id, UserAgg{ID: "u1"}); return err }, http.MethodPost, "/api/events/{id}/participants"},
+		{"leave event", func(c *HTTPClient, id string) error { _, err := c.LeaveEvent(ctx, id, id); return err }, http.MethodDelete, "/api/events/{id}/participants/{id}"},
+		{"get user", func(c *HTTPClient, id string) error { _, err := c.GetUser(ctx, id); return err }, http.MethodGet, "/api/users/{id}"},
+	}
+	ids := []struct {
+		id      string
+		escaped string
+	}{
+		{"6650c4d2a1b2c3d4e5f60718", "6650c4d2a1b2c3d4e5f60718"},
+		{"a/b", "a%2Fb"},
+		{"../users/1", "..%2Fusers%2F1"},
+		{"..", "%2E%2E"},
+		{"x?y#z", "x%3Fy%23z"},
+		{"活動 1", "%E6%B4%BB%E5%8B%95%201"},
+	}
+
+	for _, call := range calls {
+		for _, id := range ids {
+			t.Run(call.name+" "+id.id, func(t *testing.T) {
+				api := &apiRecorder{status: http.StatusOK, body: "{}"}
+				client := newAPIServer(t, api)
+				if err := call.call(client, id.id); err != nil {
+					t.Fatal(err)
+				}
+				r := api.requests[0]
+				want := strings.ReplaceAll(call.path, "{id}", id.escaped)
+				if r.Method != call.method || r.URL.EscapedPath() != want {
+					t.Errorf("got %s %s, want %s %s", r.Method, r.URL.EscapedPath(), call.method, want)
+				}
+				if r.URL.RawQuery != "" {
+					t.Errorf("query %q leaked from the ID", r.URL.RawQuery)
+				}
+			})
+		}
+	}
+}
+
+func TestHTTPClientRefusals(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		call    func(c *HTTPClient) error
+		wantErr error
+	}{
+		{"full", http.StatusConflict, `{"code":"event_full","message":"no seats left"}`,
+			func(c *HTTPClient) error { _, err := c.JoinEvent(ctx, "e1", UserAgg{ID: "u1"}); return err }, ErrEventFull},
+		{"closed", http.StatusUnprocessableEntity, `{"code":"registration_closed"}`,
+			func(c *HTTPClient) error { _, err := c.JoinEvent(ctx, "e1", UserAgg{ID: "u1"}); return err }, ErrRegistrationClosed},
+		{"already joined by message", http.StatusConflict, `{"message":"Already joined this event"}`,
+			func(c *HTTPClient) error { _, err := c.JoinEvent(ctx, "e1", UserAgg{ID: "u1"}); return err }, ErrAlreadyJoined},
+		{"started", http.StatusConflict, `{"code":"event_started"}`,
+			func(c *HTTPClient) error { _, err := c.LeaveEvent(ctx, "e1", "u1"); return err }, ErrEventStarted},
+		{"not joined", http.StatusUnprocessableEntity, `{"error":"not a participant of this event","code":"not_joined"}`,
+			func(c *HTTPClient) error { _, err := c.LeaveEvent(ctx, "e1", "u1"); return err }, ErrNotJoined},
+		{"cancelled", http.StatusConflict, `{"code":"event_cancelled"}`,
+			func(c *HTTPClient) error { _, err := c.CancelEvent(ctx, "e1", "rain"); return err }, ErrEventCancelled},
+		{"limit", http.StatusUnprocessableEntity, `{"code":"limit_below_participants"}`,
+			func(c *HTTPClient) error {
+				_, err := c.UpdateEvent(ctx, EventDetail{Event: Event{ID: "e1"}})
+				return err
+			}, ErrLimitBelowParticipants},
+		{"not found", http.StatusNotFound, `{"message":"no such event"}`,
+			func(c *HTTPClient) error { _, err := c.JoinEvent(ctx, "e1", UserAgg{ID: "u1"}); return err }, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newAPIServer(t, &apiRecorder{status: tt.status, body: tt.body})
+			err := tt.call(client)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != ErrNotFound && eventRefusal("join", "e1", err) == nil {
+				t.Errorf("%v is not reported as a refusal", err)
+			}
+		})
+	}
+
+	t.Run("unknown refusal", func(t *testing.T) {
+		client := newAPIServer(t, &apiRecorder{status: http.StatusConflict, body: `{"code":"something_else"}`})
+		_, err := client.JoinEvent(ctx, "e1", UserAgg{ID: "u1"})
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Unwrap() != nil {
+			t.Fatalf("err = %v", err)
+		}
+		if code := backendError(err, "failed", "失敗").Code; code != CodeFailedPrecondition {
+			t.Errorf("code %s", code)
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		client := newAPIServer(t, &apiRecorder{status: http.StatusBadGateway, body: "bad gateway"})
+		_, err := client.JoinEvent(ctx, "e1", UserAgg{ID: "u1"})
+		if code := backendError(err, "failed", "失敗").Code; code != CodeUnavailable {
+			t.Errorf("code %s for %v", code, err)
+		}
+	})
+}
+
+func TestHTTPClientRequests(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	past := false
+	begin := now.Add(-time.Hour).Truncate(time.Second)
+
+	upcoming := Event{ID: "e1", Date: NewTime(now.Add(24 * time.Hour))}
+	ended := Event{ID: "e2", Date: NewTime(now.Add(-48 * time.Hour)), DateEnd: NewTime(now.Add(-47 * time.Hour))}
+	body, _ := json.Marshal([]Event{upcoming, ended})
+	api := &apiRecorder{status: http.StatusOK, body: string(body)}
+	client := newAPIServer(t, api)
+
+	events, err := client.GetEvents(ctx, EventFilter{PeriodFilter: PeriodFilter{Past: &past, Begin: &begin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != "e1" {
+		t.Errorf("events %+v, want only e1", events)
+	}
+	r := api.requests[0]
+	if r.URL.Path != "/api/events" || r.URL.Query().Get("event_past") != "false" || r.URL.Query().Get("event_period_begin") != begin.Format(time.RFC3339) {
+		t.Errorf("request %s", r.URL)
+	}
+	if r.Header.Get("X-Token") != "secret" {
+		t.Errorf("auth header %q", r.Header.Get("X-Token"))
+	}
+
+	api.body = "{}"
+	if _, err := client.JoinEvent(ctx, "e1", UserAgg{ID: "u1", Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.bodies[1]; got != `{"user_id":"u1"}` {
+		t.Errorf("join body %s", got)
+	}
+	if ct := api.requests[1].Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type %q", ct)
+	}
+}
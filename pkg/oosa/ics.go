@@ -0,0 +1,280 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// EventICSResourceURITemplate serves one event as an iCalendar file.
+	EventICSResourceURITemplate = "oosa://events/{events_id}.ics"
+
+	icsSuffix   = ".ics"
+	icsMIMEType = "text/calendar"
+	// icsLineLimit is the longest content line RFC 5545 allows, in octets,
+	// not counting the CRLF.
+	icsLineLimit = 75
+	// icsLocalLayout and icsUTCLayout are the DATE-TIME forms of RFC 5545.
+	icsLocalLayout = "20060102T150405"
+	icsUTCLayout   = "20060102T150405Z"
+	// icsReminderLead is how long before the sign-up deadline the alarm fires.
+	icsReminderLead = 24 * time.Hour
+)
+
+// EventICSResourceURI returns the URI of the iCalendar file of one event.
+func EventICSResourceURI(eventID string) string {
+	return EventResourceURI(eventID) + icsSuffix
+}
+
+// icsEscape escapes a TEXT value (RFC 5545, section 3.3.11).
+func icsEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// icsFold writes one content line to b, folded so that no line is longer
+// than icsLineLimit octets. Lines are only broken between characters, so
+// multi-byte UTF-8 sequences stay intact.
+func icsFold(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the space, which counts too.
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// icsCalendar builds an iCalendar (RFC 5545) document holding a VEVENT for
+// each event, with times in Asia/Taipei.
+type icsCalendar struct {
+	b   strings.Builder
+	now time.Time
+}
+
+func (c *icsCalendar) line(name, value string) {
+	icsFold(&c.b, name+":"+value)
+}
+
+func (c *icsCalendar) text(name, value string) {
+	c.line(name, icsEscape(value))
+}
+
+func (c *icsCalendar) begin() {
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", "-//OOSA//oosa-mcp-server//EN")
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	c.line("X-WR-TIMEZONE", "Asia/Taipei")
+	// Taiwan has no daylight saving time, so one STANDARD rule covers it.
+	c.line("BEGIN", "VTIMEZONE")
+	c.line("TZID", "Asia/Taipei")
+	c.line("BEGIN", "STANDARD")
+	c.line("DTSTART", "19700101T000000")
+	c.line("TZOFFSETFROM", "+0800")
+	c.line("TZOFFSETTO", "+0800")
+	c.line("TZNAME", "CST")
+	c.line("END", "STANDARD")
+	c.line("END", "VTIMEZONE")
+}
+
+func (c *icsCalendar) end() string {
+	c.line("END", "VCALENDAR")
+	return c.b.String()
+}
+
+// localTime writes a DATE-TIME property in Asia/Taipei. Unset times are left
+// out.
+func (c *icsCalendar) localTime(name string, t Time) {
+	if t.IsZero() {
+		return
+	}
+	c.line(name+";TZID=Asia/Taipei", t.In(taipei).Format(icsLocalLayout))
+}
+
+func (c *icsCalendar) event(e EventDetail) {
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", e.ID+"@oosa")
+	c.line("DTSTAMP", c.now.UTC().Format(icsUTCLayout))
+	if e.CreatedAt != nil && !e.CreatedAt.IsZero() {
+		c.line("CREATED", e.CreatedAt.UTC().Format(icsUTCLayout))
+	}
+	c.localTime("DTSTART", e.Date)
+	c.localTime("DTEND", e.DateEnd)
+	c.text("SUMMARY", e.Name)
+	if e.Place != "" {
+		c.text("LOCATION", e.Place)
+	}
+	if e.Lat != 0 || e.Lng != 0 {
+		c.line("GEO", strconv.FormatFloat(e.Lat, 'f', -1, 64)+";"+strconv.FormatFloat(e.Lng, 'f', -1, 64))
+	}
+	c.text("DESCRIPTION", icsDescription(e))
+	if e.Type != "" {
+		c.text("CATEGORIES", e.Type)
+	}
+	if e.Status == EventStatusCancelled {
+		c.line("STATUS", "CANCELLED")
+	} else {
+		c.line("STATUS", "CONFIRMED")
+		c.alarm(e)
+	}
+	c.line("END", "VEVENT")
+}
+
+// alarm reminds the user to sign up icsReminderLead before the deadline. The
+// trigger is absolute, as the deadline is not tied to the event start.
+func (c *icsCalendar) alarm(e EventDetail) {
+	if e.Deadline.IsZero() {
+		return
+	}
+	c.line("BEGIN", "VALARM")
+	c.line("ACTION", "DISPLAY")
+	c.line("TRIGGER;VALUE=DATE-TIME", e.Deadline.Add(-icsReminderLead).UTC().Format(icsUTCLayout))
+	c.text("DESCRIPTION", fmt.Sprintf("報名截止 Sign-up closes %s: %s", e.Deadline.Display(taipei), e.Name))
+	c.line("END", "VALARM")
+}
+
+// icsDescription gathers what participants need to know before they go.
+func icsDescription(e EventDetail) string {
+	var lines []string
+	if e.Status == EventStatusCancelled {
+		line := "活動已取消 Cancelled"
+		if e.CancelReason != "" {
+			line += ": " + e.CancelReason
+		}
+		lines = append(lines, line)
+	}
+	if e.MeetingPointName != "" {
+		lines = append(lines, "集合地點 Meeting point: "+e.MeetingPointName)
+	}
+	if e.PaymentRequired != 0 {
+		lines = append(lines, "費用 Fee: NT$"+strconv.FormatFloat(e.PaymentFee, 'f', -1, 64))
+	} else {
+		lines = append(lines, "費用 Fee: 免費 free")
+	}
+	if !e.Deadline.IsZero() {
+		lines = append(lines, "報名截止 Sign-up deadline: "+e.Deadline.Display(taipei))
+	}
+	if e.Description != "" {
+		lines = append(lines, "", e.Description)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EventsICS renders events as an iCalendar document.
+func EventsICS(events []EventDetail, now time.Time) string {
+	var c icsCalendar
+	c.now = now
+	c.begin()
+	for _, e := range events {
+		c.event(e)
+	}
+	return c.end()
+}
+
+// exportEventsICSArgs are the arguments of export_events_ics.
+type exportEventsICSArgs struct {
+	EventID string `arg:"events_id" desc:"The ID of the event to export; the filters are ignored when set"`
+	eventFilterArgs
+}
+
+func ExportEventsICS(store EventStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("export_events_ics",
+			mcp.WithDescription("Export OOSA events as an iCalendar (.ics) file for phone and desktop calendars. Pass events_id for one event, or the get_events filters for several; unless event_past or a period is given, only upcoming and ongoing events are exported"),
+			WithArgs(exportEventsICSArgs{}),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args exportEventsICSArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			if args.EventID != "" {
+				event, err := store.GetEvent(ctx, args.EventID)
+				if err != nil {
+					return eventLookupError(args.EventID, err).Result(), nil
+				}
+				return mcp.NewToolResultText(EventsICS([]EventDetail{*event}, time.Now())), nil
+			}
+
+			filter, err := args.filter(taipei)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			// A calendar wants what is coming, not every event ever held.
+			if filter.PeriodFilter.IsZero() {
+				upcoming := false
+				filter.Past = &upcoming
+			}
+
+			events, err := store.GetEvents(ctx, filter)
+			if err != nil {
+				return backendError(err, "failed to get events", "無法取得活動列表").Result(), nil
+			}
+			sort.SliceStable(events, func(i, j int) bool {
+				return eventCursorKey(events[i]).less(eventCursorKey(events[j]))
+			})
+
+			details := make([]EventDetail, len(events))
+			for i, e := range events {
+				details[i] = EventDetail{Event: e}
+			}
+			return mcp.NewToolResultText(EventsICS(details, time.Now())), nil
+		}
+}
+
+// readEventICS serves the iCalendar file of one event.
+func readEventICS(ctx context.Context, store EventStore, uri, eventID string) ([]mcp.ResourceContents, error) {
+	event, err := store.GetEvent(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("event not found: %s", eventID)
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: icsMIMEType,
+			Text:     EventsICS([]EventDetail{*event}, time.Now()),
+		},
+	}, nil
+}
+
+func EventICSResource(store EventStore) (template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+	return mcp.NewResourceTemplate(EventICSResourceURITemplate, "OOSA event calendar file",
+			mcp.WithTemplateDescription("One event as an iCalendar (.ics) file, with a reminder before its sign-up deadline"),
+			mcp.WithTemplateMIMEType(icsMIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			eventID, err := resourceArg(request, "events_id")
+			if err != nil {
+				return nil, err
+			}
+			return readEventICS(ctx, store, request.Params.URI, eventID)
+		}
+}
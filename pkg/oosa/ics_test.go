@@ -0,0 +1,45 @@
+package oosa
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+var icsUID = regexp.MustCompile(`(?m)^UID:(.+)@oosa\r?$`)
+
+func TestExportEventsICSDefaultsToUpcoming(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	var created EventDetail
+	client.as("user1").callTool("create_event", newEventArgs("七星山日出"), &created)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []string
+	}{
+		{"no filters", map[string]any{}, []string{created.ID}},
+		{"category only", map[string]any{"type": "hiking"}, []string{created.ID}},
+		{"past", map[string]any{"event_past": "true"}, []string{"event1", "event2", "event3", "event4", "event5"}},
+		{"period", map[string]any{"event_period_begin": "2025-04-13", "event_period_end": "2025-04-13"}, []string{"event2", "event3"}},
+		{"period and category", map[string]any{"event_period_begin": "2025-01-01", "type": "hiking"}, []string{"event1", "event5", created.ID}},
+		{"one event", map[string]any{"events_id": "event2"}, []string{"event2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := client.tryTool("export_events_ics", tt.args)
+			if isError {
+				t.Fatal(text)
+			}
+			var ids []string
+			for _, m := range icsUID.FindAllStringSubmatch(text, -1) {
+				ids = append(ids, m[1])
+			}
+			slices.Sort(ids)
+			want := slices.Sorted(slices.Values(tt.want))
+			if !slices.Equal(ids, want) {
+				t.Errorf("exported %v, want %v", ids, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package oosa
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// getIdeasArgs are the filters of get_ideas.
+type getIdeasArgs struct {
+	Past        string `arg:"idea_past" desc:"\"true\" for ideas whose proposed date has passed, \"false\" for upcoming ones; omit for both"`
+	PeriodBegin string `arg:"idea_period_begin" desc:"The beginning of the idea period, as RFC3339 (2025-04-11T16:00:00Z) or a date (2025-04-11, Asia/Taipei unless timezone says otherwise); ideas ending before it are excluded"`
+	PeriodEnd   string `arg:"idea_period_end" desc:"The end of the idea period, as RFC3339 or a date (inclusive, Asia/Taipei unless timezone says otherwise); ideas starting after it are excluded"`
+}
+
+func GetIdeas(store IdeaStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("get_ideas",
+			mcp.WithDescription("List OOSA ideas, outings proposed by users that are not scheduled as events yet, ordered by proposed date. Filters work like get_events. Results are paginated: pass next_cursor back as cursor to get the next page"),
+			WithArgs(getIdeasArgs{}),
+			WithOutput(),
+			WithPagination(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args getIdeasArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			pagination, err := OptionalPaginationParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			filter, err := ParseIdeaFilter(args.Past, args.PeriodBegin, args.PeriodEnd, output.Location)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			ideas, err := store.GetIdeas(ctx, filter)
+			if err != nil {
+				return backendError(err, "failed to get ideas", "無法取得提案").Result(), nil
+			}
+
+			page, err := paginateIdeas(ideas, pagination)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			return renderResult(page, output)
+		}
+}
@@ -0,0 +1,117 @@
+package oosa
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ideaIDs lists the IDs of the ideas get_ideas returns for args.
+func ideaIDs(t *testing.T, client *testClient, args map[string]any) []string {
+	t.Helper()
+	args["format"] = "json"
+	var page IdeasPage
+	client.callTool("get_ideas", args, &page)
+	var ids []string
+	for _, i := range page.Ideas {
+		ids = append(ids, i.ID)
+	}
+	return ids
+}
+
+func TestGetIdeasFields(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	text, isError := client.tryTool("get_ideas", map[string]any{"perPage": 1.0, "format": "json"})
+	if isError {
+		t.Fatal(text)
+	}
+
+	var page struct {
+		Ideas      []map[string]any `json:"ideas"`
+		TotalCount int              `json:"total_count"`
+		NextCursor string           `json:"next_cursor"`
+		HasMore    bool             `json:"has_more"`
+	}
+	if err := json.Unmarshal([]byte(text), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Ideas) != 1 || page.TotalCount != 3 || page.NextCursor == "" || !page.HasMore {
+		t.Fatalf("page:\n%s", text)
+	}
+	idea := page.Ideas[0]
+	for key, want := range map[string]any{
+		"ideas_id":    "idea1",
+		"ideas_title": "陽明山擎天崗看芒草",
+		"ideas_place": "擎天崗",
+		"ideas_date":  "2025-05-17T09:00:00+08:00",
+		"ideas_lat":   25.1669,
+	} {
+		if idea[key] != want {
+			t.Errorf("%s = %v, want %v", key, idea[key], want)
+		}
+	}
+	if by, _ := idea["ideas_created_by_user"].(map[string]any); by["user_id"] != "user2" {
+		t.Errorf("ideas_created_by_user = %v", idea["ideas_created_by_user"])
+	}
+	// Every key is snake_case, as in the OOSA API.
+	for _, key := range regexp.MustCompile(`"(\w+)":`).FindAllStringSubmatch(text, -1) {
+		if key[1] != strings.ToLower(key[1]) {
+			t.Errorf("key %s is not snake_case", key[1])
+		}
+	}
+}
+
+func TestGetIdeasFilters(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	tests := []struct {
+		args map[string]any
+		want []string
+	}{
+		{map[string]any{}, []string{"idea1", "idea2", "idea3"}},
+		// The mock ideas were all proposed for 2025.
+		{map[string]any{"idea_past": "true"}, []string{"idea1", "idea2", "idea3"}},
+		{map[string]any{"idea_past": "false"}, nil},
+		{map[string]any{"idea_period_begin": "2025-05-20"}, []string{"idea2", "idea3"}},
+		{map[string]any{"idea_period_end": "2025-05-24"}, []string{"idea1", "idea2"}},
+		{map[string]any{"idea_period_begin": "2025-05-20", "idea_period_end": "2025-05-31"}, []string{"idea2"}},
+	}
+	for _, tt := range tests {
+		if ids := ideaIDs(t, client, tt.args); !slices.Equal(ids, tt.want) {
+			t.Errorf("%v: %v, want %v", tt.args, ids, tt.want)
+		}
+	}
+
+	for name, value := range map[string]any{
+		"idea_past":         "maybe",
+		"idea_period_begin": "next week",
+		"perPage":           101.0,
+		"cursor":            "not a cursor",
+	} {
+		if text, isError := client.tryTool("get_ideas", map[string]any{name: value}); !isError || !strings.Contains(text, `"code":"invalid_argument"`) {
+			t.Errorf("%s=%v: %s", name, value, text)
+		}
+	}
+}
+
+func TestGetIdeasPages(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+
+	var first, second IdeasPage
+	client.callTool("get_ideas", map[string]any{"perPage": 2.0, "format": "json"}, &first)
+	if len(first.Ideas) != 2 || first.Ideas[0].ID != "idea1" || first.Ideas[1].ID != "idea2" || !first.HasMore {
+		t.Fatalf("first page %+v", first)
+	}
+	client.callTool("get_ideas", map[string]any{"perPage": 2.0, "format": "json", "cursor": first.NextCursor}, &second)
+	if len(second.Ideas) != 1 || second.Ideas[0].ID != "idea3" || second.HasMore || second.NextCursor != "" || second.TotalCount != 3 {
+		t.Errorf("second page %+v", second)
+	}
+
+	// Pages are cut after filtering.
+	var filtered IdeasPage
+	client.callTool("get_ideas", map[string]any{"perPage": 1.0, "format": "json", "idea_period_begin": "2025-05-20"}, &filtered)
+	if len(filtered.Ideas) != 1 || filtered.Ideas[0].ID != "idea2" || filtered.TotalCount != 2 || !filtered.HasMore {
+		t.Errorf("filtered page %+v", filtered)
+	}
+}
@@ -0,0 +1,74 @@
+package oosa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultIndexRefreshInterval is how old the event indexes may get before a
+// query rescans the backend.
+const DefaultIndexRefreshInterval = 30 * time.Second
+
+// eventIndex is an index over events that an IndexRefresher keeps current.
+type eventIndex interface {
+	// mark returns the generation of the index, which every Upsert advances.
+	mark() uint64
+	// sync makes the index hold exactly events, the result of a full scan
+	// started at generation mark. Events upserted since are newer than what
+	// the scan read, so they are left alone.
+	sync(events []Event, mark uint64)
+}
+
+// IndexRefresher keeps event indexes in step with the backend. Writes made
+// through this server reach them at once through Upsert; the changes made
+// elsewhere, by the OOSA app, other replicas or directly in the database,
+// are picked up by rescanning the backend once the last scan is older than
+// the refresh interval.
+type IndexRefresher struct {
+	store    EventStore
+	indexes  []eventIndex
+	interval time.Duration
+	now      func() time.Time
+
+	// mu serialises refresh, so that concurrent queries scan once.
+	mu        sync.Mutex
+	refreshed time.Time
+}
+
+// NewIndexRefresher keeps indexes current with store. now is the clock the
+// refresh interval is measured with.
+func NewIndexRefresher(store EventStore, interval time.Duration, now func() time.Time, indexes ...eventIndex) *IndexRefresher {
+	return &IndexRefresher{
+		store:    store,
+		indexes:  indexes,
+		interval: interval,
+		now:      now,
+	}
+}
+
+// Refresh rescans the backend if the indexes have never been filled or
+// their last scan is older than the refresh interval. A failed scan is
+// retried by the next call.
+func (r *IndexRefresher) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.refreshed.IsZero() && r.now().Sub(r.refreshed) < r.interval {
+		return nil
+	}
+
+	marks := make([]uint64, len(r.indexes))
+	for i, ix := range r.indexes {
+		marks[i] = ix.mark()
+	}
+	started := r.now()
+	events, err := r.store.GetEvents(ctx, EventFilter{})
+	if err != nil {
+		return err
+	}
+	for i, ix := range r.indexes {
+		ix.sync(events, marks[i])
+	}
+	r.refreshed = started
+	return nil
+}
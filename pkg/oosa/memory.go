@@ -0,0 +1,296 @@
+package oosa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps OOSA data in memory. It backs MockClient and FileClient.
+type memoryStore struct {
+	mu     sync.RWMutex
+	users  []UserAgg
+	events []Event
+	ideas  []Idea
+	// descriptions and participants hold the detail-only data, keyed by event ID.
+	// Participants are kept in the order they joined.
+	descriptions map[string]string
+	participants map[string][]UserAgg
+}
+
+func newMemoryStore(users []UserAgg, events []Event, ideas []Idea) *memoryStore {
+	s := &memoryStore{
+		users:        users,
+		events:       events,
+		ideas:        ideas,
+		descriptions: make(map[string]string),
+		participants: make(map[string][]UserAgg),
+	}
+	for i := range s.events {
+		if s.events[i].Status == "" {
+			s.events[i].Status = EventStatusActive
+		}
+	}
+	// Without a full participant list, fall back to the latest three users,
+	// which are listed newest first.
+	for _, e := range events {
+		if e.Participants == nil {
+			continue
+		}
+		latest := e.Participants.LatestThreeUser
+		list := make([]UserAgg, 0, len(latest))
+		for i := len(latest) - 1; i >= 0; i-- {
+			list = append(list, latest[i])
+		}
+		s.participants[e.ID] = list
+	}
+	return s
+}
+
+func (s *memoryStore) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filterEvents(s.events, filter, time.Now()), nil
+}
+
+func (s *memoryStore) GetEvent(ctx context.Context, eventID string) (*EventDetail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.eventDetail(eventID)
+}
+
+// eventDetail builds the detail of one event. Callers must hold s.mu.
+func (s *memoryStore) eventDetail(eventID string) (*EventDetail, error) {
+	for _, e := range s.events {
+		if e.ID != eventID {
+			continue
+		}
+		detail := &EventDetail{
+			Event:           e,
+			Description:     s.descriptions[e.ID],
+			ParticipantList: append([]UserAgg{}, s.participants[e.ID]...),
+		}
+		if e.CreatedByUser != nil {
+			detail.Host = &UserProfile{
+				UserAgg:      *e.CreatedByUser,
+				EventsHosted: s.countHosted(e.CreatedByUser.ID),
+			}
+		}
+		return detail, nil
+	}
+	return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+}
+
+// newEventID returns a random ID shaped like a MongoDB ObjectID.
+func newEventID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *memoryStore) CreateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := event.Event
+	e.ID = newEventID()
+	e.CreatedAt = timePtr(NewTime(time.Now().UTC()))
+	e.Status = EventStatusActive
+	e.Participants = &EventsParticipants{
+		LatestThreeUser: []UserAgg{},
+		RemainNumber:    int64(e.ParticipantLimit),
+	}
+	s.events = append(s.events, e)
+	if event.Description != "" {
+		s.descriptions[e.ID] = event.Description
+	}
+	return s.eventDetail(e.ID)
+}
+
+// eventIndex returns the position of an event in s.events. Callers must hold s.mu.
+func (s *memoryStore) eventIndex(eventID string) (int, error) {
+	for i := range s.events {
+		if s.events[i].ID == eventID {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+}
+
+// setParticipants replaces the participant list of the event at index i and
+// refreshes its summary. The summary is replaced rather than modified, since
+// copies of the event handed out earlier share it. Callers must hold s.mu.
+func (s *memoryStore) setParticipants(i int, list []UserAgg, remain int64) {
+	e := &s.events[i]
+	s.participants[e.ID] = list
+
+	latest := make([]UserAgg, 0, 3)
+	for j := len(list) - 1; j >= 0 && len(latest) < 3; j-- {
+		latest = append(latest, list[j])
+	}
+	e.Participants = &EventsParticipants{
+		LatestThreeUser: latest,
+		RemainNumber:    remain,
+	}
+}
+
+// remainingSeats returns the free seats of e. Callers must hold s.mu.
+func (s *memoryStore) remainingSeats(e Event) int64 {
+	if e.Participants != nil {
+		return e.Participants.RemainNumber
+	}
+	return int64(e.ParticipantLimit) - int64(len(s.participants[e.ID]))
+}
+
+func (s *memoryStore) JoinEvent(ctx context.Context, eventID string, user UserAgg) (*EventDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.eventIndex(eventID)
+	if err != nil {
+		return nil, err
+	}
+	e := s.events[i]
+
+	if e.Status == EventStatusCancelled {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventCancelled)
+	}
+	if !e.Deadline.IsZero() && !time.Now().Before(e.Deadline.Time) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrRegistrationClosed)
+	}
+	list := s.participants[eventID]
+	for _, p := range list {
+		if p.ID == user.ID {
+			return nil, fmt.Errorf("event %s: %w", eventID, ErrAlreadyJoined)
+		}
+	}
+	remain := s.remainingSeats(e)
+	if remain <= 0 {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventFull)
+	}
+
+	joined := make([]UserAgg, len(list), len(list)+1)
+	copy(joined, list)
+	s.setParticipants(i, append(joined, user), remain-1)
+	return s.eventDetail(eventID)
+}
+
+func (s *memoryStore) LeaveEvent(ctx context.Context, eventID string, userID string) (*EventDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.eventIndex(eventID)
+	if err != nil {
+		return nil, err
+	}
+	e := s.events[i]
+
+	if !e.Date.IsZero() && !time.Now().Before(e.Date.Time) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventStarted)
+	}
+	list := s.participants[eventID]
+	kept := make([]UserAgg, 0, len(list))
+	for _, p := range list {
+		if p.ID != userID {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(list) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotJoined)
+	}
+
+	remain := s.remainingSeats(e) + 1
+	if limit := int64(e.ParticipantLimit); remain > limit {
+		remain = limit
+	}
+	s.setParticipants(i, kept, remain)
+	return s.eventDetail(eventID)
+}
+
+func (s *memoryStore) UpdateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.eventIndex(event.ID)
+	if err != nil {
+		return nil, err
+	}
+	old := s.events[i]
+	if old.Status == EventStatusCancelled {
+		return nil, fmt.Errorf("event %s: %w", event.ID, ErrEventCancelled)
+	}
+
+	remain := s.remainingSeats(old) + int64(event.ParticipantLimit) - int64(old.ParticipantLimit)
+	if remain < 0 {
+		return nil, fmt.Errorf("event %s: %w", event.ID, ErrLimitBelowParticipants)
+	}
+
+	e := event.Event
+	e.CreatedByUser = old.CreatedByUser
+	e.CreatedAt = old.CreatedAt
+	e.Status = old.Status
+	e.CancelReason = old.CancelReason
+	e.CancelledAt = old.CancelledAt
+	e.Participants = &EventsParticipants{RemainNumber: remain, LatestThreeUser: []UserAgg{}}
+	if old.Participants != nil {
+		e.Participants.LatestThreeUser = old.Participants.LatestThreeUser
+	}
+	s.events[i] = e
+	s.descriptions[e.ID] = event.Description
+	return s.eventDetail(e.ID)
+}
+
+func (s *memoryStore) CancelEvent(ctx context.Context, eventID string, reason string) (*EventDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.eventIndex(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if s.events[i].Status == EventStatusCancelled {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventCancelled)
+	}
+
+	s.events[i].Status = EventStatusCancelled
+	s.events[i].CancelReason = reason
+	s.events[i].CancelledAt = timePtr(NewTime(time.Now().UTC()))
+	return s.eventDetail(eventID)
+}
+
+// countHosted returns the number of events created by userID. Callers must hold s.mu.
+func (s *memoryStore) countHosted(userID string) int {
+	n := 0
+	for _, e := range s.events {
+		if e.CreatedByUser != nil && e.CreatedByUser.ID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *memoryStore) GetIdeas(ctx context.Context, filter IdeaFilter) ([]Idea, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filterIdeas(s.ideas, filter, time.Now()), nil
+}
+
+func (s *memoryStore) GetUser(ctx context.Context, userID string) (*UserAgg, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.users {
+		if s.users[i].ID == userID {
+			user := s.users[i]
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+}
+
+func (s *memoryStore) Close(ctx context.Context) error {
+	return nil
+}
@@ -1,57 +1,21 @@
 package oosa
 
-import (
-	"context"
-	"time"
-)
-
-type Client struct {
-}
-
-type Event struct {
-	ID               string              `json:"events_id"`
-	Name             string              `json:"events_name"`
-	Date             string              `json:"events_date"`
-	DateEnd          string              `json:"events_date_end"`
-	Deadline         string              `json:"events_deadline"`
-	Place            string              `json:"events_place"`
-	Lat              float64             `json:"events_lat"`
-	Lng              float64             `json:"events_lng"`
-	MeetingPointName string              `json:"events_meeting_point_name"`
-	MeetingPointLat  float64             `json:"events_meeting_point_lat"`
-	MeetingPointLng  float64             `json:"events_meeting_point_lng"`
-	ParticipantLimit float64             `json:"events_participant_limit"`
-	PaymentRequired  int64               `json:"events_payment_required"`
-	PaymentFee       float64             `json:"events_payment_fee"`
-	Photo            string              `json:"events_photo"`
-	Type             string              `json:"events_type"`
-	CreatedByUser    *UserAgg            `json:"events_created_by_user,omitempty"`
-	Participants     *EventsParticipants `json:"events_participants,omitempty"`
-	CreatedAt        *string             `json:"events_created_at,omitempty"`
-}
-
-type UserAgg struct {
-	ID     string `json:"user_id"`
-	Name   string `json:"user_name"`
-	Email  string `json:"user_email"`
-	Avatar string `json:"user_avatar"`
-}
-
-type EventsParticipants struct {
-	LatestThreeUser []UserAgg `json:"latest_tree_user"`
-	RemainNumber    int64     `json:"remain_number"`
+// MockClient serves a fixed set of Taipei events, handy for development and demos.
+type MockClient struct {
+	*memoryStore
 }
 
-type Idea struct {
-	ID          string
-	Title       string
-	Description string
-	CreatedAt   time.Time
+// NewMockClient creates a MockClient loaded with the built-in fixtures.
+func NewMockClient() *MockClient {
+	users := mockUsers()
+	store := newMemoryStore(users, mockEvents(users), mockIdeas(users))
+	store.descriptions = mockDescriptions()
+	return &MockClient{memoryStore: store}
 }
 
-func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBegin string, eventPeriodEnd string) ([]Event, error) {
-	// 建立一些測試用戶
-	users := []UserAgg{
+// mockUsers 建立一些測試用戶
+func mockUsers() []UserAgg {
+	return []UserAgg{
 		{
 			ID:     "user1",
 			Name:   "王小明",
@@ -71,15 +35,17 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 			Avatar: "https://example.com/avatar3.jpg",
 		},
 	}
+}
 
-	// 建立活動列表
-	events := []Event{
+// mockEvents 建立活動列表
+func mockEvents(users []UserAgg) []Event {
+	return []Event{
 		{
 			ID:               "event1",
 			Name:             "象山步道健行賞夜景",
-			Date:             "2025-04-11T16:00:00Z",
-			DateEnd:          "2025-04-11T20:00:00Z",
-			Deadline:         "2025-04-10T23:59:59Z",
+			Date:             mustParseTime("2025-04-11T16:00:00Z"),
+			DateEnd:          mustParseTime("2025-04-11T20:00:00Z"),
+			Deadline:         mustParseTime("2025-04-10T23:59:59Z"),
 			Place:            "象山步道",
 			Lat:              25.0330,
 			Lng:              121.5700,
@@ -96,14 +62,14 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 				LatestThreeUser: users,
 				RemainNumber:    12,
 			},
-			CreatedAt: strPtr("2025-03-11T12:00:00Z"),
+			CreatedAt: timePtr(mustParseTime("2025-03-11T12:00:00Z")),
 		},
 		{
 			ID:               "event2",
 			Name:             "大稻埕老街文化導覽",
-			Date:             "2025-04-12T14:00:00Z",
-			DateEnd:          "2025-04-12T18:00:00Z",
-			Deadline:         "2025-04-11T23:59:59Z",
+			Date:             mustParseTime("2025-04-12T14:00:00Z"),
+			DateEnd:          mustParseTime("2025-04-12T18:00:00Z"),
+			Deadline:         mustParseTime("2025-04-11T23:59:59Z"),
 			Place:            "大稻埕",
 			Lat:              25.0550,
 			Lng:              121.5100,
@@ -120,14 +86,14 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 				LatestThreeUser: users[:2],
 				RemainNumber:    17,
 			},
-			CreatedAt: strPtr("2025-03-12T12:00:00Z"),
+			CreatedAt: timePtr(mustParseTime("2025-03-12T12:00:00Z")),
 		},
 		{
 			ID:               "event3",
 			Name:             "北投溫泉泡湯之旅",
-			Date:             "2025-04-13T13:00:00Z",
-			DateEnd:          "2025-04-13T17:00:00Z",
-			Deadline:         "2025-04-12T23:59:59Z",
+			Date:             mustParseTime("2025-04-13T13:00:00Z"),
+			DateEnd:          mustParseTime("2025-04-13T17:00:00Z"),
+			Deadline:         mustParseTime("2025-04-12T23:59:59Z"),
 			Place:            "北投溫泉博物館",
 			Lat:              25.1370,
 			Lng:              121.5070,
@@ -144,14 +110,14 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 				LatestThreeUser: users[1:],
 				RemainNumber:    7,
 			},
-			CreatedAt: strPtr("2025-03-13T12:00:00Z"),
+			CreatedAt: timePtr(mustParseTime("2025-03-13T12:00:00Z")),
 		},
 		{
 			ID:               "event4",
 			Name:             "士林夜市美食探索",
-			Date:             "2025-04-14T18:00:00Z",
-			DateEnd:          "2025-04-14T22:00:00Z",
-			Deadline:         "2025-04-13T23:59:59Z",
+			Date:             mustParseTime("2025-04-14T18:00:00Z"),
+			DateEnd:          mustParseTime("2025-04-14T22:00:00Z"),
+			Deadline:         mustParseTime("2025-04-13T23:59:59Z"),
 			Place:            "士林夜市",
 			Lat:              25.0880,
 			Lng:              121.5200,
@@ -168,14 +134,14 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 				LatestThreeUser: users[:2],
 				RemainNumber:    5,
 			},
-			CreatedAt: strPtr("2025-03-14T12:00:00Z"),
+			CreatedAt: timePtr(mustParseTime("2025-03-14T12:00:00Z")),
 		},
 		{
 			ID:               "event5",
 			Name:             "陽明山賞花健行",
-			Date:             "2025-04-15T09:00:00Z",
-			DateEnd:          "2025-04-15T15:00:00Z",
-			Deadline:         "2025-04-14T23:59:59Z",
+			Date:             mustParseTime("2025-04-15T09:00:00Z"),
+			DateEnd:          mustParseTime("2025-04-15T15:00:00Z"),
+			Deadline:         mustParseTime("2025-04-14T23:59:59Z"),
 			Place:            "陽明山國家公園",
 			Lat:              25.1700,
 			Lng:              121.5400,
@@ -192,38 +158,60 @@ func (c *Client) GetEvents(ctx context.Context, eventPast string, eventPeriodBeg
 				LatestThreeUser: users,
 				RemainNumber:    9,
 			},
-			CreatedAt: strPtr("2025-03-15T12:00:00Z"),
+			CreatedAt: timePtr(mustParseTime("2025-03-15T12:00:00Z")),
 		},
 	}
+}
 
-	return events, nil
+// mockDescriptions 建立活動介紹
+func mockDescriptions() map[string]string {
+	return map[string]string{
+		"event1": "傍晚從象山捷運站出發，沿象山步道登上六巨石，欣賞台北101與市區夜景。步道階梯較多，請穿著好走的鞋並自備飲水與頭燈。",
+		"event2": "由在地導覽員帶領走訪大稻埕老街、霞海城隍廟與迪化街南北貨商行，了解百年茶商與布市的歷史，最後在大稻埕碼頭欣賞夕陽。費用含導覽與講義。",
+		"event3": "參觀北投溫泉博物館與地熱谷，之後前往公共浴場泡湯。費用含門票與泡湯券，請自備毛巾與換洗衣物。",
+		"event4": "跟著美食達人逛士林夜市，品嚐大餅包小餅、豪大雞排與青蛙下蛋等經典小吃，餐費各自負擔。",
+		"event5": "從劍潭捷運站搭乘公車上山，走訪陽明公園與花鐘賞花，沿途步道平緩適合新手。費用含往返交通與保險。",
+	}
 }
 
-func (c *Client) GetIdeas(ctx context.Context, ideaPast string, ideaPeriodBegin string, ideaPeriodEnd string) ([]Idea, error) {
-	ideas := []Idea{
+// mockIdeas 建立點子列表
+func mockIdeas(users []UserAgg) []Idea {
+	return []Idea{
 		{
-			ID:          "1",
-			Title:       "測試點子1",
-			Description: "這是一個測試點子的描述",
-			CreatedAt:   time.Now().Add(-24 * time.Hour),
+			ID:            "idea1",
+			Title:         "陽明山擎天崗看芒草",
+			Description:   "秋天的擎天崗滿山芒草，想找人一起從冷水坑走到擎天崗草原，順便看水牛。",
+			Date:          mustParseTime("2025-05-17T01:00:00Z"),
+			DateEnd:       mustParseTime("2025-05-17T06:00:00Z"),
+			Place:         "擎天崗",
+			Lat:           25.1669,
+			Lng:           121.5745,
+			CreatedByUser: &users[1],
+			CreatedAt:     timePtr(mustParseTime("2025-04-01T10:00:00Z")),
 		},
 		{
-			ID:          "2",
-			Title:       "測試點子2",
-			Description: "這是另一個測試點子的描述",
-			CreatedAt:   time.Now().Add(-48 * time.Hour),
+			ID:            "idea2",
+			Title:         "河濱自行車夕陽騎行",
+			Description:   "從大稻埕碼頭租 YouBike 沿淡水河騎到關渡，看夕陽再搭捷運回來。",
+			Date:          mustParseTime("2025-05-24T08:30:00Z"),
+			DateEnd:       mustParseTime("2025-05-24T12:00:00Z"),
+			Place:         "大稻埕碼頭",
+			Lat:           25.0562,
+			Lng:           121.5083,
+			CreatedByUser: &users[0],
+			CreatedAt:     timePtr(mustParseTime("2025-04-03T09:00:00Z")),
 		},
 		{
-			ID:          "3",
-			Title:       "測試點子3",
-			Description: "這是第三個測試點子的描述",
-			CreatedAt:   time.Now().Add(-72 * time.Hour),
+			ID:            "idea3",
+			Title:         "貓空纜車喝茶",
+			Description:   "搭貓空纜車上山，找一間茶館喝鐵觀音、吃茶餐，適合想放鬆的下午。",
+			Date:          mustParseTime("2025-06-07T06:00:00Z"),
+			DateEnd:       mustParseTime("2025-06-07T10:00:00Z"),
+			Place:         "貓空",
+			Lat:           24.9686,
+			Lng:           121.5881,
+			CreatedByUser: &users[2],
+			CreatedAt:     timePtr(mustParseTime("2025-04-05T14:00:00Z")),
 		},
 	}
-	return ideas, nil
-}
-
-// Helper function to create string pointer
-func strPtr(s string) *string {
-	return &s
 }
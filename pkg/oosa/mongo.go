@@ -0,0 +1,907 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Collection names used by the OOSA backend.
+const (
+	mongoEventsCollection       = "events"
+	mongoUsersCollection        = "users"
+	mongoParticipantsCollection = "events_participants"
+	mongoIdeasCollection        = "ideas"
+)
+
+// MongoConfig holds the settings needed to reach the OOSA MongoDB database.
+type MongoConfig struct {
+	URI      string
+	Database string
+	Timeout  time.Duration
+}
+
+// mongoParticipantKeys are the keys of the unique index NewMongoClient
+// creates on the participants collection, so that concurrent joins cannot
+// record a user twice.
+var mongoParticipantKeys = bson.D{
+	{Key: "events_participants_event", Value: 1},
+	{Key: "events_participants_user", Value: 1},
+}
+
+// mongoCollection is the subset of *mongo.Collection used by MongoClient, so
+// that an in-process stand-in can replace a live cluster.
+type mongoCollection interface {
+	Aggregate(ctx context.Context, pipeline any, opts ...options.Lister[options.AggregateOptions]) (*mongo.Cursor, error)
+	Find(ctx context.Context, filter any, opts ...options.Lister[options.FindOptions]) (*mongo.Cursor, error)
+	FindOne(ctx context.Context, filter any, opts ...options.Lister[options.FindOneOptions]) *mongo.SingleResult
+	CountDocuments(ctx context.Context, filter any, opts ...options.Lister[options.CountOptions]) (int64, error)
+	InsertOne(ctx context.Context, document any, opts ...options.Lister[options.InsertOneOptions]) (*mongo.InsertOneResult, error)
+	UpdateOne(ctx context.Context, filter any, update any, opts ...options.Lister[options.UpdateOneOptions]) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter any, opts ...options.Lister[options.DeleteOneOptions]) (*mongo.DeleteResult, error)
+}
+
+// MongoClient reads and writes OOSA data straight in MongoDB.
+type MongoClient struct {
+	client       *mongo.Client
+	events       mongoCollection
+	users        mongoCollection
+	ideas        mongoCollection
+	participants mongoCollection
+	timeout      time.Duration
+}
+
+// NewMongoClient connects to MongoDB and verifies the connection with a ping.
+func NewMongoClient(ctx context.Context, cfg MongoConfig) (*MongoClient, error) {
+	if cfg.URI == "" {
+		return nil, fmt.Errorf("mongo uri is required")
+	}
+	if cfg.Database == "" {
+		return nil, fmt.Errorf("mongo database is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(cfg.URI).SetTimeout(cfg.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongo: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	if err := client.Ping(pingCtx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping mongo: %w", err)
+	}
+
+	db := client.Database(cfg.Database)
+	participants := db.Collection(mongoParticipantsCollection)
+	indexCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	_, err = participants.Indexes().CreateOne(indexCtx, mongo.IndexModel{
+		Keys:    mongoParticipantKeys,
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to create participant index: %w", err)
+	}
+
+	return &MongoClient{
+		client:       client,
+		events:       db.Collection(mongoEventsCollection),
+		users:        db.Collection(mongoUsersCollection),
+		ideas:        db.Collection(mongoIdeasCollection),
+		participants: participants,
+		timeout:      cfg.Timeout,
+	}, nil
+}
+
+// Close disconnects from MongoDB.
+func (c *MongoClient) Close(ctx context.Context) error {
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Disconnect(ctx)
+}
+
+// mongoUser is the document shape of the users collection.
+type mongoUser struct {
+	ID     bson.ObjectID `bson:"_id"`
+	Name   string        `bson:"user_name"`
+	Email  string        `bson:"user_email"`
+	Avatar string        `bson:"user_avatar"`
+}
+
+func (u mongoUser) toUserAgg() UserAgg {
+	return UserAgg{
+		ID:     u.ID.Hex(),
+		Name:   u.Name,
+		Email:  u.Email,
+		Avatar: u.Avatar,
+	}
+}
+
+// mongoIdea is the shape produced by ideasPipeline.
+type mongoIdea struct {
+	ID            bson.ObjectID `bson:"_id"`
+	Title         string        `bson:"ideas_title"`
+	Description   string        `bson:"ideas_description"`
+	Date          time.Time     `bson:"ideas_date"`
+	DateEnd       time.Time     `bson:"ideas_date_end"`
+	Place         string        `bson:"ideas_place"`
+	Lat           float64       `bson:"ideas_lat"`
+	Lng           float64       `bson:"ideas_lng"`
+	CreatedByUser *mongoUser    `bson:"ideas_created_by_user,omitempty"`
+	CreatedAt     *time.Time    `bson:"ideas_created_at,omitempty"`
+}
+
+func (i mongoIdea) toIdea() Idea {
+	idea := Idea{
+		ID:          i.ID.Hex(),
+		Title:       i.Title,
+		Description: i.Description,
+		Date:        mongoTime(i.Date),
+		DateEnd:     mongoTime(i.DateEnd),
+		Place:       i.Place,
+		Lat:         i.Lat,
+		Lng:         i.Lng,
+	}
+	if i.CreatedByUser != nil {
+		user := i.CreatedByUser.toUserAgg()
+		idea.CreatedByUser = &user
+	}
+	if i.CreatedAt != nil {
+		idea.CreatedAt = timePtr(mongoTime(*i.CreatedAt))
+	}
+	return idea
+}
+
+// mongoEventDoc is the stored shape of an event, as written by MongoClient.
+type mongoEventDoc struct {
+	ID               bson.ObjectID `bson:"_id"`
+	Name             string        `bson:"events_name"`
+	Date             time.Time     `bson:"events_date"`
+	DateEnd          time.Time     `bson:"events_date_end,omitempty"`
+	Deadline         time.Time     `bson:"events_deadline"`
+	Place            string        `bson:"events_place"`
+	Lat              float64       `bson:"events_lat"`
+	Lng              float64       `bson:"events_lng"`
+	MeetingPointName string        `bson:"events_meeting_point_name"`
+	MeetingPointLat  float64       `bson:"events_meeting_point_lat"`
+	MeetingPointLng  float64       `bson:"events_meeting_point_lng"`
+	ParticipantLimit float64       `bson:"events_participant_limit"`
+	PaymentRequired  int64         `bson:"events_payment_required"`
+	PaymentFee       float64       `bson:"events_payment_fee"`
+	Photo            string        `bson:"events_photo"`
+	Type             string        `bson:"events_type"`
+	Description      string        `bson:"events_description"`
+	CreatedBy        bson.ObjectID `bson:"events_created_by"`
+	SeatsTaken       int64         `bson:"events_participants_count"`
+	Status           EventStatus   `bson:"events_status"`
+	CreatedAt        time.Time     `bson:"events_created_at"`
+}
+
+// newMongoEventDoc converts a validated event into its stored shape.
+func newMongoEventDoc(event EventDetail) (mongoEventDoc, error) {
+	if event.CreatedByUser == nil {
+		return mongoEventDoc{}, fmt.Errorf("event has no host")
+	}
+	host, err := bson.ObjectIDFromHex(event.CreatedByUser.ID)
+	if err != nil {
+		return mongoEventDoc{}, fmt.Errorf("user %s: %w", event.CreatedByUser.ID, ErrNotFound)
+	}
+
+	return mongoEventDoc{
+		ID:               bson.NewObjectID(),
+		Name:             event.Name,
+		Date:             event.Date.Time,
+		DateEnd:          event.DateEnd.Time,
+		Deadline:         event.Deadline.Time,
+		Place:            event.Place,
+		Lat:              event.Lat,
+		Lng:              event.Lng,
+		MeetingPointName: event.MeetingPointName,
+		MeetingPointLat:  event.MeetingPointLat,
+		MeetingPointLng:  event.MeetingPointLng,
+		ParticipantLimit: event.ParticipantLimit,
+		PaymentRequired:  event.PaymentRequired,
+		PaymentFee:       event.PaymentFee,
+		Photo:            event.Photo,
+		Type:             event.Type,
+		Description:      event.Description,
+		CreatedBy:        host,
+		Status:           EventStatusActive,
+		CreatedAt:        time.Now().UTC(),
+	}, nil
+}
+
+// mongoEvent is the shape produced by eventsPipeline.
+type mongoEvent struct {
+	ID               bson.ObjectID `bson:"_id"`
+	Name             string        `bson:"events_name"`
+	Date             time.Time     `bson:"events_date"`
+	DateEnd          time.Time     `bson:"events_date_end"`
+	Deadline         time.Time     `bson:"events_deadline"`
+	Place            string        `bson:"events_place"`
+	Lat              float64       `bson:"events_lat"`
+	Lng              float64       `bson:"events_lng"`
+	MeetingPointName string        `bson:"events_meeting_point_name"`
+	MeetingPointLat  float64       `bson:"events_meeting_point_lat"`
+	MeetingPointLng  float64       `bson:"events_meeting_point_lng"`
+	ParticipantLimit float64       `bson:"events_participant_limit"`
+	PaymentRequired  int64         `bson:"events_payment_required"`
+	PaymentFee       float64       `bson:"events_payment_fee"`
+	Photo            string        `bson:"events_photo"`
+	Type             string        `bson:"events_type"`
+	Description      string        `bson:"events_description"`
+	CreatedByUser    *mongoUser    `bson:"events_created_by_user,omitempty"`
+	Participants     *struct {
+		LatestThreeUser []mongoUser `bson:"latest_three_user"`
+		RemainNumber    int64       `bson:"remain_number"`
+	} `bson:"events_participants,omitempty"`
+	CreatedAt    *time.Time  `bson:"events_created_at,omitempty"`
+	Status       EventStatus `bson:"events_status"`
+	CancelReason string      `bson:"events_cancel_reason"`
+	CancelledAt  *time.Time  `bson:"events_cancelled_at,omitempty"`
+}
+
+func (e mongoEvent) toEvent() Event {
+	event := Event{
+		ID:               e.ID.Hex(),
+		Name:             e.Name,
+		Date:             mongoTime(e.Date),
+		DateEnd:          mongoTime(e.DateEnd),
+		Deadline:         mongoTime(e.Deadline),
+		Place:            e.Place,
+		Lat:              e.Lat,
+		Lng:              e.Lng,
+		MeetingPointName: e.MeetingPointName,
+		MeetingPointLat:  e.MeetingPointLat,
+		MeetingPointLng:  e.MeetingPointLng,
+		ParticipantLimit: e.ParticipantLimit,
+		PaymentRequired:  e.PaymentRequired,
+		PaymentFee:       e.PaymentFee,
+		Photo:            e.Photo,
+		Type:             e.Type,
+		Status:           e.Status,
+		CancelReason:     e.CancelReason,
+	}
+	if event.Status == "" {
+		event.Status = EventStatusActive
+	}
+	if e.CancelledAt != nil {
+		event.CancelledAt = timePtr(mongoTime(*e.CancelledAt))
+	}
+	if e.CreatedByUser != nil {
+		user := e.CreatedByUser.toUserAgg()
+		event.CreatedByUser = &user
+	}
+	if e.Participants != nil {
+		users := make([]UserAgg, 0, len(e.Participants.LatestThreeUser))
+		for _, u := range e.Participants.LatestThreeUser {
+			users = append(users, u.toUserAgg())
+		}
+		event.Participants = &EventsParticipants{
+			LatestThreeUser: users,
+			RemainNumber:    e.Participants.RemainNumber,
+		}
+	}
+	if e.CreatedAt != nil {
+		event.CreatedAt = timePtr(mongoTime(*e.CreatedAt))
+	}
+	return event
+}
+
+// mongoTime converts a stored timestamp into the Time used by Event.
+func mongoTime(t time.Time) Time {
+	return NewTime(t.UTC())
+}
+
+// eventsPipeline joins every event with its host and a participants summary:
+// the three most recent participants and the number of seats left.
+func eventsPipeline(match bson.D) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if len(match) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "events_date", Value: 1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: mongoUsersCollection},
+			{Key: "localField", Value: "events_created_by"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "events_created_by_user"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$events_created_by_user"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: mongoParticipantsCollection},
+			{Key: "let", Value: bson.D{{Key: "event_id", Value: "$_id"}}},
+			{Key: "pipeline", Value: participantsPipeline()},
+			{Key: "as", Value: "events_participants"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$events_participants"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "events_participants.remain_number", Value: bson.D{{Key: "$max", Value: bson.A{
+				0,
+				bson.D{{Key: "$subtract", Value: bson.A{
+					"$events_participant_limit",
+					bson.D{{Key: "$ifNull", Value: bson.A{"$events_participants.total", 0}}},
+				}}},
+			}}}},
+			{Key: "events_participants.latest_three_user", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$events_participants.latest_three_user", bson.A{}}}}},
+		}}},
+		bson.D{{Key: "$unset", Value: bson.A{"events_participants.total", "events_created_by"}}},
+	)
+}
+
+// participantsPipeline runs inside the events_participants lookup and folds the
+// participants of one event into {latest_three_user, total}.
+func participantsPipeline() mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "$expr", Value: bson.D{
+			{Key: "$eq", Value: bson.A{"$events_participants_event", "$$event_id"}},
+		}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "events_participants_created_at", Value: -1}}}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "latest", Value: mongo.Pipeline{
+				bson.D{{Key: "$limit", Value: 3}},
+				bson.D{{Key: "$lookup", Value: bson.D{
+					{Key: "from", Value: mongoUsersCollection},
+					{Key: "localField", Value: "events_participants_user"},
+					{Key: "foreignField", Value: "_id"},
+					{Key: "as", Value: "user"},
+				}}},
+				bson.D{{Key: "$unwind", Value: "$user"}},
+				bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$user"}}}},
+			}},
+			{Key: "count", Value: mongo.Pipeline{
+				bson.D{{Key: "$count", Value: "n"}},
+			}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "latest_three_user", Value: "$latest"},
+			{Key: "total", Value: bson.D{{Key: "$ifNull", Value: bson.A{
+				bson.D{{Key: "$arrayElemAt", Value: bson.A{"$count.n", 0}}},
+				0,
+			}}}},
+		}}},
+	}
+}
+
+// periodMatch translates a PeriodFilter into a $match stage body over the
+// given start and end fields. Like PeriodFilter.matchPeriod, it takes a
+// missing end to equal the start and never matches an item without a start.
+func periodMatch(f PeriodFilter, startField, endField string, now time.Time) bson.D {
+	if f.IsZero() {
+		return bson.D{}
+	}
+
+	var endedBefore, endsAfter *time.Time
+	if f.Past != nil {
+		if *f.Past {
+			endedBefore = &now
+		} else {
+			endsAfter = &now
+		}
+	}
+	if f.Begin != nil && (endsAfter == nil || f.Begin.After(*endsAfter)) {
+		endsAfter = f.Begin
+	}
+
+	startConds := bson.D{{Key: "$ne", Value: nil}}
+	if f.End != nil {
+		startConds = append(startConds, bson.E{Key: "$lte", Value: *f.End})
+	}
+	match := bson.D{{Key: startField, Value: startConds}}
+
+	end := bson.D{{Key: "$ifNull", Value: bson.A{"$" + endField, "$" + startField}}}
+	endConds := bson.A{}
+	if endedBefore != nil {
+		endConds = append(endConds, bson.D{{Key: "$lt", Value: bson.A{end, *endedBefore}}})
+	}
+	if endsAfter != nil {
+		endConds = append(endConds, bson.D{{Key: "$gte", Value: bson.A{end, *endsAfter}}})
+	}
+	if len(endConds) > 0 {
+		match = append(match, bson.E{Key: "$expr", Value: bson.D{{Key: "$and", Value: endConds}}})
+	}
+	return match
+}
+
+// categoryMatch selects the events of a category by every spelling of it;
+// CategoryOther selects the events spelled like no other category. Like
+// LookupCategory it ignores case and surrounding spaces.
+func categoryMatch(id CategoryID) bson.E {
+	if id != CategoryOther {
+		c, _ := LookupCategory(string(id))
+		return bson.E{Key: "events_type", Value: bson.D{{Key: "$in", Value: bson.A{spellingsRegex(categorySpellings(c))}}}}
+	}
+	var known []string
+	for _, c := range Categories {
+		if c.ID != CategoryOther {
+			known = append(known, categorySpellings(c)...)
+		}
+	}
+	return bson.E{Key: "events_type", Value: bson.D{{Key: "$nin", Value: bson.A{spellingsRegex(known)}}}}
+}
+
+// spellingsRegex matches any of spellings, ignoring case and surrounding
+// spaces.
+func spellingsRegex(spellings []string) bson.Regex {
+	quoted := make([]string, len(spellings))
+	for i, s := range spellings {
+		quoted[i] = regexp.QuoteMeta(s)
+	}
+	return bson.Regex{Pattern: `^\s*(?:` + strings.Join(quoted, "|") + `)\s*$`, Options: "i"}
+}
+
+// ideasPipeline joins every idea with its author, earliest proposed date first.
+func ideasPipeline(match bson.D) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if len(match) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "ideas_date", Value: 1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: mongoUsersCollection},
+			{Key: "localField", Value: "ideas_created_by"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "ideas_created_by_user"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$ideas_created_by_user"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		bson.D{{Key: "$unset", Value: bson.A{"ideas_created_by"}}},
+	)
+}
+
+func (c *MongoClient) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	match := periodMatch(filter.PeriodFilter, "events_date", "events_date_end", time.Now())
+	if filter.Category != "" {
+		match = append(match, categoryMatch(filter.Category))
+	}
+	cursor, err := c.events.Aggregate(ctx, eventsPipeline(match))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoEvent
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode events: %w", err)
+	}
+
+	events := make([]Event, 0, len(docs))
+	for _, doc := range docs {
+		events = append(events, doc.toEvent())
+	}
+	return events, nil
+}
+
+// participantListPipeline lists every participant of an event in the order they joined.
+func participantListPipeline(eventID bson.ObjectID) mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "events_participants_event", Value: eventID}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "events_participants_created_at", Value: 1}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: mongoUsersCollection},
+			{Key: "localField", Value: "events_participants_user"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "user"},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$user"}},
+		bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$user"}}}},
+	}
+}
+
+func (c *MongoClient) GetEvent(ctx context.Context, eventID string) (*EventDetail, error) {
+	id, err := bson.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cursor, err := c.events.Aggregate(ctx, eventsPipeline(bson.D{{Key: "_id", Value: id}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate event: %w", err)
+	}
+	var docs []mongoEvent
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+	}
+
+	cursor, err = c.participants.Aggregate(ctx, participantListPipeline(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate participants: %w", err)
+	}
+	var participants []mongoUser
+	if err := cursor.All(ctx, &participants); err != nil {
+		return nil, fmt.Errorf("failed to decode participants: %w", err)
+	}
+
+	detail := &EventDetail{
+		Event:           docs[0].toEvent(),
+		Description:     docs[0].Description,
+		ParticipantList: make([]UserAgg, 0, len(participants)),
+	}
+	for _, u := range participants {
+		detail.ParticipantList = append(detail.ParticipantList, u.toUserAgg())
+	}
+
+	if host := docs[0].CreatedByUser; host != nil {
+		hosted, err := c.events.CountDocuments(ctx, bson.D{{Key: "events_created_by", Value: host.ID}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count hosted events: %w", err)
+		}
+		detail.Host = &UserProfile{
+			UserAgg:      host.toUserAgg(),
+			EventsHosted: int(hosted),
+		}
+	}
+
+	return detail, nil
+}
+
+func (c *MongoClient) CreateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	doc, err := newMongoEventDoc(event)
+	if err != nil {
+		return nil, err
+	}
+
+	insertCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if _, err := c.events.InsertOne(insertCtx, doc); err != nil {
+		return nil, fmt.Errorf("failed to insert event: %w", err)
+	}
+
+	return c.GetEvent(ctx, doc.ID.Hex())
+}
+
+// UpdateEvent only matches while the event is active and its seat counter
+// fits the new limit, so a concurrent join cannot slip past a lowered limit.
+func (c *MongoClient) UpdateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	id, err := bson.ObjectIDFromHex(event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", event.ID, ErrNotFound)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if err := c.ensureSeatCount(writeCtx, id); err != nil {
+		return nil, err
+	}
+
+	set := bson.D{
+		{Key: "events_name", Value: event.Name},
+		{Key: "events_date", Value: event.Date.Time},
+		{Key: "events_deadline", Value: event.Deadline.Time},
+		{Key: "events_place", Value: event.Place},
+		{Key: "events_lat", Value: event.Lat},
+		{Key: "events_lng", Value: event.Lng},
+		{Key: "events_meeting_point_name", Value: event.MeetingPointName},
+		{Key: "events_meeting_point_lat", Value: event.MeetingPointLat},
+		{Key: "events_meeting_point_lng", Value: event.MeetingPointLng},
+		{Key: "events_participant_limit", Value: event.ParticipantLimit},
+		{Key: "events_payment_required", Value: event.PaymentRequired},
+		{Key: "events_payment_fee", Value: event.PaymentFee},
+		{Key: "events_photo", Value: event.Photo},
+		{Key: "events_type", Value: event.Type},
+		{Key: "events_description", Value: event.Description},
+	}
+	// An event without an end has no end field, which periodMatch reads as
+	// ending when it starts.
+	var unset bson.D
+	if event.DateEnd.IsZero() {
+		unset = bson.D{{Key: "events_date_end", Value: ""}}
+	} else {
+		set = append(set, bson.E{Key: "events_date_end", Value: event.DateEnd.Time})
+	}
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+
+	res, err := c.events.UpdateOne(writeCtx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "events_status", Value: bson.D{{Key: "$ne", Value: EventStatusCancelled}}},
+			{Key: mongoSeatsField, Value: bson.D{{Key: "$lte", Value: event.ParticipantLimit}}},
+		},
+		update,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+	if res.MatchedCount == 1 {
+		return c.GetEvent(ctx, event.ID)
+	}
+
+	// Nothing matched: work out which condition failed.
+	detail, err := c.GetEvent(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	if detail.Status == EventStatusCancelled {
+		return nil, fmt.Errorf("event %s: %w", event.ID, ErrEventCancelled)
+	}
+	return nil, fmt.Errorf("event %s: %w", event.ID, ErrLimitBelowParticipants)
+}
+
+func (c *MongoClient) CancelEvent(ctx context.Context, eventID string, reason string) (*EventDetail, error) {
+	id, err := bson.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	res, err := c.events.UpdateOne(writeCtx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "events_status", Value: bson.D{{Key: "$ne", Value: EventStatusCancelled}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "events_status", Value: EventStatusCancelled},
+			{Key: "events_cancel_reason", Value: reason},
+			{Key: "events_cancelled_at", Value: time.Now().UTC()},
+		}}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel event: %w", err)
+	}
+
+	detail, err := c.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventCancelled)
+	}
+	return detail, nil
+}
+
+// mongoSeatsField counts the participants of an event on the event document
+// itself, so a seat can be taken with a single conditional update. Events
+// written before it existed get it on their first join or leave.
+const mongoSeatsField = "events_participants_count"
+
+// ensureSeatCount initialises the seat counter of an event from its
+// participants. It is a no-op once the counter exists.
+func (c *MongoClient) ensureSeatCount(ctx context.Context, eventID bson.ObjectID) error {
+	n, err := c.participants.CountDocuments(ctx, bson.D{{Key: "events_participants_event", Value: eventID}})
+	if err != nil {
+		return fmt.Errorf("failed to count participants: %w", err)
+	}
+	_, err = c.events.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: eventID}, {Key: mongoSeatsField, Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: mongoSeatsField, Value: n}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to initialise seat count: %w", err)
+	}
+	return nil
+}
+
+// recountSeats drops the seat counter of an event and counts it again from
+// the participants, for when a write left it out of step.
+func (c *MongoClient) recountSeats(ctx context.Context, eventID bson.ObjectID) error {
+	_, err := c.events.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: eventID}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: mongoSeatsField, Value: ""}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset seat count: %w", err)
+	}
+	return c.ensureSeatCount(ctx, eventID)
+}
+
+// registrationOpen matches the events that still take sign-ups at now: those
+// whose deadline is ahead, and those that have none, which is stored as a
+// missing field, null or the zero time.
+func registrationOpen(now time.Time) bson.E {
+	return bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: "events_deadline", Value: bson.D{{Key: "$gt", Value: now}}}},
+		bson.D{{Key: "events_deadline", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "events_deadline", Value: nil}},
+		bson.D{{Key: "events_deadline", Value: bson.D{{Key: "$lte", Value: time.Time{}}}}},
+	}}
+}
+
+// JoinEvent records the participant first, so that a second join by the same
+// user is caught, by the upsert or, when two joins race, by the unique index
+// on mongoParticipantKeys, and then takes a seat with a conditional increment that
+// only matches while registration is open and seats are left. If no seat
+// can be taken the participant record is removed again.
+func (c *MongoClient) JoinEvent(ctx context.Context, eventID string, user UserAgg) (*EventDetail, error) {
+	id, err := bson.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+	}
+	userID, err := bson.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", user.ID, ErrNotFound)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if err := c.ensureSeatCount(writeCtx, id); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	participant := bson.D{
+		{Key: "events_participants_event", Value: id},
+		{Key: "events_participants_user", Value: userID},
+	}
+	res, err := c.participants.UpdateOne(writeCtx, participant,
+		bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "events_participants_created_at", Value: now}}}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrAlreadyJoined)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add participant: %w", err)
+	}
+	if res.UpsertedCount == 0 {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrAlreadyJoined)
+	}
+
+	res, err = c.events.UpdateOne(writeCtx,
+		bson.D{
+			{Key: "_id", Value: id},
+			{Key: "events_status", Value: bson.D{{Key: "$ne", Value: EventStatusCancelled}}},
+			registrationOpen(now),
+			{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{"$" + mongoSeatsField, "$events_participant_limit"}}}},
+		},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: mongoSeatsField, Value: 1}}}},
+	)
+	if err == nil && res.MatchedCount == 1 {
+		return c.GetEvent(ctx, eventID)
+	}
+
+	if _, delErr := c.participants.DeleteOne(writeCtx, participant); delErr != nil {
+		return nil, fmt.Errorf("failed to roll back participant: %w", delErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take seat: %w", err)
+	}
+
+	// Nothing matched: work out which condition failed.
+	detail, err := c.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if detail.Status == EventStatusCancelled {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventCancelled)
+	}
+	if !detail.Deadline.IsZero() && !now.Before(detail.Deadline.Time) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrRegistrationClosed)
+	}
+	return nil, fmt.Errorf("event %s: %w", eventID, ErrEventFull)
+}
+
+func (c *MongoClient) LeaveEvent(ctx context.Context, eventID string, userID string) (*EventDetail, error) {
+	id, err := bson.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotFound)
+	}
+	uid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotJoined)
+	}
+
+	detail, err := c.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !detail.Date.IsZero() && !time.Now().Before(detail.Date.Time) {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrEventStarted)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if err := c.ensureSeatCount(writeCtx, id); err != nil {
+		return nil, err
+	}
+
+	res, err := c.participants.DeleteOne(writeCtx, bson.D{
+		{Key: "events_participants_event", Value: id},
+		{Key: "events_participants_user", Value: uid},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return nil, fmt.Errorf("event %s: %w", eventID, ErrNotJoined)
+	}
+
+	_, err = c.events.UpdateOne(writeCtx,
+		bson.D{{Key: "_id", Value: id}, {Key: mongoSeatsField, Value: bson.D{{Key: "$gt", Value: 0}}}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: mongoSeatsField, Value: -1}}}},
+	)
+	if err != nil {
+		// The participant is gone, so the user has left. Count the seats
+		// again rather than keep theirs taken for good.
+		if err := c.recountSeats(writeCtx, id); err != nil {
+			return nil, fmt.Errorf("left event %s but failed to free seat: %w", eventID, err)
+		}
+	}
+
+	return c.GetEvent(ctx, eventID)
+}
+
+func (c *MongoClient) GetIdeas(ctx context.Context, filter IdeaFilter) ([]Idea, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cursor, err := c.ideas.Aggregate(ctx, ideasPipeline(periodMatch(filter.PeriodFilter, "ideas_date", "ideas_date_end", time.Now())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate ideas: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoIdea
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode ideas: %w", err)
+	}
+
+	ideas := make([]Idea, 0, len(docs))
+	for _, doc := range docs {
+		ideas = append(ideas, doc.toIdea())
+	}
+	return ideas, nil
+}
+
+func (c *MongoClient) GetUser(ctx context.Context, userID string) (*UserAgg, error) {
+	id, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var doc mongoUser
+	if err := c.users.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	user := doc.toUserAgg()
+	return &user, nil
+}
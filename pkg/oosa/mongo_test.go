@@ -0,0 +1,691 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// testObjectID returns a fixed ObjectID ending in n.
+func testObjectID(n byte) bson.ObjectID {
+	var id bson.ObjectID
+	id[11] = n
+	return id
+}
+
+// Fixture users and events of newMongoFixture.
+var (
+	mongoHostID  = testObjectID(1)
+	mongoUserIDs = []bson.ObjectID{testObjectID(2), testObjectID(3), testObjectID(4), testObjectID(5), testObjectID(6)}
+
+	mongoPastID      = testObjectID(11)
+	mongoSoonID      = testObjectID(12)
+	mongoLaterID     = testObjectID(13)
+	mongoBowlingID   = testObjectID(14)
+	mongoClosedID    = testObjectID(15)
+	mongoCancelledID = testObjectID(16)
+)
+
+// mongoFixture is a MongoClient over a fakeMongo seeded with events around now.
+type mongoFixture struct {
+	client *MongoClient
+	db     *fakeMongo
+	now    time.Time
+}
+
+func newMongoFixture(t *testing.T) *mongoFixture {
+	t.Helper()
+	client, db := newFakeMongoClient()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	at := func(hours int) time.Time { return now.Add(time.Duration(hours) * time.Hour) }
+
+	users := db.collection(mongoUsersCollection)
+	users.insert(mongoUser{ID: mongoHostID, Name: "Host", Email: "host@example.com", Avatar: "host.png"})
+	for i, id := range mongoUserIDs {
+		users.insert(mongoUser{ID: id, Name: "User " + string(rune('A'+i))})
+	}
+
+	event := func(id bson.ObjectID, name, kind string, start, end, deadline time.Time, limit float64, seats int64) mongoEventDoc {
+		return mongoEventDoc{
+			ID: id, Name: name, Type: kind,
+			Date: start, DateEnd: end, Deadline: deadline,
+			ParticipantLimit: limit, SeatsTaken: seats,
+			CreatedBy: mongoHostID, Status: EventStatusActive, CreatedAt: at(-240),
+		}
+	}
+	cancelled := event(mongoCancelledID, "Cancelled", "健行", at(48), at(52), at(40), 5, 0)
+	cancelled.Status = EventStatusCancelled
+	db.collection(mongoEventsCollection).insert(
+		event(mongoPastID, "Past hike", "健行", at(-48), at(-44), at(-72), 10, 0),
+		event(mongoSoonID, "Night market", "food tour", at(24), at(28), at(12), 5, 4),
+		event(mongoLaterID, "Hot spring", "溫泉", at(240), at(244), at(200), 10, 0),
+		event(mongoBowlingID, "Bowling", "Bowling", at(72), at(74), at(60), 8, 0),
+		event(mongoClosedID, "Closed", "美食", at(6), at(8), at(-1), 8, 0),
+		cancelled,
+	)
+
+	participants := db.collection(mongoParticipantsCollection)
+	for i, user := range mongoUserIDs[:4] {
+		participants.insert(bson.D{
+			{Key: "events_participants_event", Value: mongoSoonID},
+			{Key: "events_participants_user", Value: user},
+			{Key: "events_participants_created_at", Value: at(-100 + i)},
+		})
+	}
+
+	db.collection(mongoIdeasCollection).insert(
+		bson.D{{Key: "_id", Value: testObjectID(21)}, {Key: "ideas_title", Value: "Old idea"}, {Key: "ideas_date", Value: at(-30)}, {Key: "ideas_date_end", Value: at(-29)}, {Key: "ideas_created_by", Value: mongoHostID}},
+		bson.D{{Key: "_id", Value: testObjectID(22)}, {Key: "ideas_title", Value: "New idea"}, {Key: "ideas_date", Value: at(30)}, {Key: "ideas_date_end", Value: at(31)}},
+	)
+
+	return &mongoFixture{client: client, db: db, now: now}
+}
+
+func eventIDs(events []Event) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func hexIDs(ids ...bson.ObjectID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.Hex()
+	}
+	return out
+}
+
+func TestMongoGetEventsFilters(t *testing.T) {
+	f := newMongoFixture(t)
+	yes, no := true, false
+	at := func(hours int) *time.Time {
+		t := f.now.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   []string
+	}{
+		{"no filter", EventFilter{}, hexIDs(mongoPastID, mongoClosedID, mongoSoonID, mongoCancelledID, mongoBowlingID, mongoLaterID)},
+		{"past", EventFilter{PeriodFilter: PeriodFilter{Past: &yes}}, hexIDs(mongoPastID)},
+		{"upcoming", EventFilter{PeriodFilter: PeriodFilter{Past: &no}}, hexIDs(mongoClosedID, mongoSoonID, mongoCancelledID, mongoBowlingID, mongoLaterID)},
+		{"begin", EventFilter{PeriodFilter: PeriodFilter{Begin: at(100)}}, hexIDs(mongoLaterID)},
+		{"end", EventFilter{PeriodFilter: PeriodFilter{End: at(24)}}, hexIDs(mongoPastID, mongoClosedID, mongoSoonID)},
+		{"overlap", EventFilter{PeriodFilter: PeriodFilter{Begin: at(26), End: at(50)}}, hexIDs(mongoSoonID, mongoCancelledID)},
+		{"upcoming from a later begin", EventFilter{PeriodFilter: PeriodFilter{Past: &no, Begin: at(60)}}, hexIDs(mongoBowlingID, mongoLaterID)},
+		{"category by label", EventFilter{Category: "hiking"}, hexIDs(mongoPastID, mongoCancelledID)},
+		{"category by synonym", EventFilter{Category: "food"}, hexIDs(mongoClosedID, mongoSoonID)},
+		{"other category", EventFilter{Category: CategoryOther}, hexIDs(mongoBowlingID)},
+		{"category and period", EventFilter{PeriodFilter: PeriodFilter{Past: &no}, Category: "hiking"}, hexIDs(mongoCancelledID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := f.client.GetEvents(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := eventIDs(events); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMongoCategoryIgnoresCase(t *testing.T) {
+	client, db := newFakeMongoClient()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	types := []string{"HIKING", "  Food Tour ", "Hot Springs", "night VIEW", "YouBike", "健行", "bowling", "hiking club", ""}
+	events := db.collection(mongoEventsCollection)
+	for i, kind := range types {
+		events.insert(mongoEventDoc{
+			ID: testObjectID(byte(40 + i)), Name: "Event", Type: kind,
+			Date: now.Add(time.Hour), DateEnd: now.Add(2 * time.Hour), Deadline: now,
+			ParticipantLimit: 5, Status: EventStatusActive,
+		})
+	}
+
+	all, err := client.GetEvents(context.Background(), EventFilter{})
+	if err != nil || len(all) != len(types) {
+		t.Fatalf("%d events, %v", len(all), err)
+	}
+	for _, c := range Categories {
+		t.Run(c.ID, func(t *testing.T) {
+			filter := EventFilter{Category: CategoryID(c.ID)}
+			got, err := client.GetEvents(context.Background(), filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs, wantIDs := eventIDs(got), eventIDs(filterEvents(all, filter, now))
+			slices.Sort(gotIDs)
+			slices.Sort(wantIDs)
+			if !slices.Equal(gotIDs, wantIDs) {
+				t.Errorf("mongo %v, memory %v", gotIDs, wantIDs)
+			}
+		})
+	}
+}
+
+func TestMongoGetEventsSummary(t *testing.T) {
+	f := newMongoFixture(t)
+	events, err := f.client.GetEvents(context.Background(), EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range events {
+		if e.CreatedByUser == nil || e.CreatedByUser.ID != mongoHostID.Hex() {
+			t.Errorf("%s: host %+v", e.Name, e.CreatedByUser)
+		}
+		if e.Participants == nil {
+			t.Fatalf("%s: no participants summary", e.Name)
+		}
+		switch e.ID {
+		case mongoSoonID.Hex():
+			var latest []string
+			for _, u := range e.Participants.LatestThreeUser {
+				latest = append(latest, u.ID)
+			}
+			if want := hexIDs(mongoUserIDs[3], mongoUserIDs[2], mongoUserIDs[1]); !slices.Equal(latest, want) {
+				t.Errorf("latest three %v, want %v", latest, want)
+			}
+			if e.Participants.RemainNumber != 1 {
+				t.Errorf("remain %d, want 1", e.Participants.RemainNumber)
+			}
+		case mongoLaterID.Hex():
+			if len(e.Participants.LatestThreeUser) != 0 || e.Participants.RemainNumber != 10 {
+				t.Errorf("empty event summary %+v", e.Participants)
+			}
+		case mongoCancelledID.Hex():
+			if e.Status != EventStatusCancelled {
+				t.Errorf("status %q", e.Status)
+			}
+		}
+	}
+}
+
+func TestMongoGetEvent(t *testing.T) {
+	f := newMongoFixture(t)
+
+	detail, err := f.client.GetEvent(context.Background(), mongoSoonID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []string
+	for _, u := range detail.ParticipantList {
+		list = append(list, u.ID)
+	}
+	if want := hexIDs(mongoUserIDs[:4]...); !slices.Equal(list, want) {
+		t.Errorf("participant list %v, want %v", list, want)
+	}
+	if detail.Host == nil || detail.Host.ID != mongoHostID.Hex() || detail.Host.EventsHosted != 6 {
+		t.Errorf("host %+v", detail.Host)
+	}
+	if !detail.Date.Equal(f.now.Add(24 * time.Hour)) {
+		t.Errorf("date %v", detail.Date)
+	}
+
+	for _, id := range []string{"not-an-id", testObjectID(99).Hex()} {
+		if _, err := f.client.GetEvent(context.Background(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEvent(%s) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestMongoGetEventsPagination(t *testing.T) {
+	f := newMongoFixture(t)
+	_, handler := GetEvents(f.client)
+
+	var got []string
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("pagination does not end")
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"perPage": float64(4), "format": "json", "cursor": cursor}
+		result, err := handler(context.Background(), request)
+		if err != nil || result.IsError {
+			t.Fatalf("get_events: %v %+v", err, result)
+		}
+		var events EventsPage
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &events); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, eventIDs(events.Events)...)
+		if !events.HasMore {
+			break
+		}
+		cursor = events.NextCursor
+	}
+
+	want := hexIDs(mongoPastID, mongoClosedID, mongoSoonID, mongoCancelledID, mongoBowlingID, mongoLaterID)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMongoGetIdeas(t *testing.T) {
+	f := newMongoFixture(t)
+	yes, no := true, false
+
+	tests := []struct {
+		name   string
+		filter IdeaFilter
+		want   []string
+	}{
+		{"all", IdeaFilter{}, []string{"Old idea", "New idea"}},
+		{"past", IdeaFilter{PeriodFilter{Past: &yes}}, []string{"Old idea"}},
+		{"upcoming", IdeaFilter{PeriodFilter{Past: &no}}, []string{"New idea"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ideas, err := f.client.GetIdeas(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, i := range ideas {
+				got = append(got, i.Title)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ideas, _ := f.client.GetIdeas(context.Background(), IdeaFilter{})
+	if author := ideas[0].CreatedByUser; author == nil || author.ID != mongoHostID.Hex() {
+		t.Errorf("author %+v", author)
+	}
+	if ideas[1].CreatedByUser != nil {
+		t.Errorf("idea without author got %+v", ideas[1].CreatedByUser)
+	}
+}
+
+func TestMongoGetUser(t *testing.T) {
+	f := newMongoFixture(t)
+
+	user, err := f.client.GetUser(context.Background(), mongoHostID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Host" || user.Avatar != "host.png" {
+		t.Errorf("user %+v", user)
+	}
+	for _, id := range []string{"nope", testObjectID(99).Hex()} {
+		if _, err := f.client.GetUser(context.Background(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUser(%s) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestMongoCreateEvent(t *testing.T) {
+	f := newMongoFixture(t)
+	host := UserAgg{ID: mongoHostID.Hex(), Name: "Host"}
+	event := EventDetail{
+		Event: Event{
+			Name:             "New walk",
+			Date:             NewTime(f.now.Add(48 * time.Hour)),
+			DateEnd:          NewTime(f.now.Add(50 * time.Hour)),
+			Deadline:         NewTime(f.now.Add(40 * time.Hour)),
+			ParticipantLimit: 3,
+			Type:             "健行",
+			CreatedByUser:    &host,
+		},
+		Description: "Bring water",
+	}
+
+	created, err := f.client.CreateEvent(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Status != EventStatusActive || created.Description != "Bring water" {
+		t.Errorf("created %+v", created)
+	}
+	if created.Participants == nil || created.Participants.RemainNumber != 3 {
+		t.Errorf("participants %+v", created.Participants)
+	}
+	if created.Host == nil || created.Host.EventsHosted != 7 {
+		t.Errorf("host %+v", created.Host)
+	}
+
+	events, _ := f.client.GetEvents(context.Background(), EventFilter{Category: "hiking"})
+	if !slices.Contains(eventIDs(events), created.ID) {
+		t.Errorf("created event missing from %v", eventIDs(events))
+	}
+
+	event.CreatedByUser = nil
+	if _, err := f.client.CreateEvent(context.Background(), event); err == nil {
+		t.Error("event without host was created")
+	}
+}
+
+func TestMongoUpdateEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		limit   float64
+		wantErr error
+	}{
+		{"raise limit", mongoSoonID.Hex(), 8, nil},
+		{"limit equal to participants", mongoSoonID.Hex(), 4, nil},
+		{"limit below participants", mongoSoonID.Hex(), 3, ErrLimitBelowParticipants},
+		{"cancelled", mongoCancelledID.Hex(), 5, ErrEventCancelled},
+		{"unknown", testObjectID(99).Hex(), 5, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMongoFixture(t)
+			old, _ := f.client.GetEvent(context.Background(), mongoSoonID.Hex())
+			event := *old
+			event.ID = tt.id
+			event.Name = "Renamed"
+			event.ParticipantLimit = tt.limit
+
+			updated, err := f.client.UpdateEvent(context.Background(), event)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if updated.Name != "Renamed" || updated.Participants.RemainNumber != int64(tt.limit)-4 {
+				t.Errorf("updated %s with %d seats left", updated.Name, updated.Participants.RemainNumber)
+			}
+			if len(updated.ParticipantList) != 4 {
+				t.Errorf("participants changed: %d", len(updated.ParticipantList))
+			}
+		})
+	}
+}
+
+func TestMongoCancelEvent(t *testing.T) {
+	f := newMongoFixture(t)
+
+	cancelled, err := f.client.CancelEvent(context.Background(), mongoSoonID.Hex(), "typhoon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != EventStatusCancelled || cancelled.CancelReason != "typhoon" || cancelled.CancelledAt == nil {
+		t.Errorf("cancelled %+v", cancelled.Event)
+	}
+
+	if _, err := f.client.CancelEvent(context.Background(), mongoSoonID.Hex(), "again"); !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("second cancel: %v", err)
+	}
+	if _, err := f.client.CancelEvent(context.Background(), testObjectID(99).Hex(), "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown event: %v", err)
+	}
+	if _, err := f.client.JoinEvent(context.Background(), mongoSoonID.Hex(), UserAgg{ID: mongoUserIDs[4].Hex()}); !errors.Is(err, ErrEventCancelled) {
+		t.Errorf("join after cancel: %v", err)
+	}
+}
+
+func TestMongoJoinEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   bson.ObjectID
+		user    bson.ObjectID
+		wantErr error
+	}{
+		{"takes the last seat", mongoSoonID, mongoUserIDs[4], nil},
+		{"open event", mongoLaterID, mongoUserIDs[0], nil},
+		{"already joined", mongoSoonID, mongoUserIDs[0], ErrAlreadyJoined},
+		{"deadline passed", mongoClosedID, mongoUserIDs[0], ErrRegistrationClosed},
+		{"cancelled", mongoCancelledID, mongoUserIDs[0], ErrEventCancelled},
+		{"unknown", testObjectID(99), mongoUserIDs[0], ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMongoFixture(t)
+			before, _ := f.db.collection(mongoParticipantsCollection).CountDocuments(context.Background(), bson.D{})
+
+			detail, err := f.client.JoinEvent(context.Background(), tt.event.Hex(), UserAgg{ID: tt.user.Hex()})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			after, _ := f.db.collection(mongoParticipantsCollection).CountDocuments(context.Background(), bson.D{})
+			if err != nil {
+				if after != before {
+					t.Errorf("failed join left %d participants, had %d", after, before)
+				}
+				return
+			}
+			if after != before+1 {
+				t.Errorf("%d participants, want %d", after, before+1)
+			}
+			if latest := detail.Participants.LatestThreeUser; len(latest) == 0 || latest[0].ID != tt.user.Hex() {
+				t.Errorf("latest three %+v", latest)
+			}
+		})
+	}
+
+	t.Run("full", func(t *testing.T) {
+		f := newMongoFixture(t)
+		if _, err := f.client.JoinEvent(context.Background(), mongoSoonID.Hex(), UserAgg{ID: mongoUserIDs[4].Hex()}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.client.JoinEvent(context.Background(), mongoSoonID.Hex(), UserAgg{ID: mongoHostID.Hex()}); !errors.Is(err, ErrEventFull) {
+			t.Errorf("err = %v, want ErrEventFull", err)
+		}
+	})
+}
+
+func TestMongoLeaveEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   bson.ObjectID
+		user    string
+		wantErr error
+	}{
+		{"participant", mongoSoonID, mongoUserIDs[1].Hex(), nil},
+		{"not joined", mongoSoonID, mongoUserIDs[4].Hex(), ErrNotJoined},
+		{"bad user id", mongoSoonID, "nope", ErrNotJoined},
+		{"started", mongoPastID, mongoUserIDs[0].Hex(), ErrEventStarted},
+		{"unknown", testObjectID(99), mongoUserIDs[0].Hex(), ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMongoFixture(t)
+			detail, err := f.client.LeaveEvent(context.Background(), tt.event.Hex(), tt.user)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if detail.Participants.RemainNumber != 2 || len(detail.ParticipantList) != 3 {
+				t.Errorf("%d seats left, %d participants", detail.Participants.RemainNumber, len(detail.ParticipantList))
+			}
+			if _, err := f.client.JoinEvent(context.Background(), tt.event.Hex(), UserAgg{ID: tt.user}); err != nil {
+				t.Errorf("rejoin: %v", err)
+			}
+		})
+	}
+}
+
+func TestMongoJoinEventWithoutDeadline(t *testing.T) {
+	f := newMongoFixture(t)
+	events := f.db.collection(mongoEventsCollection)
+	start := f.now.Add(24 * time.Hour)
+	// Events the OOSA app stored without a deadline: zero, null or missing.
+	zero, null, missing := testObjectID(31), testObjectID(32), testObjectID(33)
+	events.insert(
+		mongoEventDoc{ID: zero, Name: "Zero", Date: start, ParticipantLimit: 2, CreatedBy: mongoHostID, Status: EventStatusActive},
+		bson.D{{Key: "_id", Value: null}, {Key: "events_name", Value: "Null"}, {Key: "events_date", Value: start}, {Key: "events_deadline", Value: nil},
+			{Key: "events_participant_limit", Value: 2}, {Key: "events_created_by", Value: mongoHostID}},
+		bson.D{{Key: "_id", Value: missing}, {Key: "events_name", Value: "Missing"}, {Key: "events_date", Value: start},
+			{Key: "events_participant_limit", Value: 2}, {Key: "events_created_by", Value: mongoHostID}},
+	)
+
+	for _, id := range []bson.ObjectID{zero, null, missing} {
+		detail, err := f.client.JoinEvent(context.Background(), id.Hex(), UserAgg{ID: mongoUserIDs[0].Hex()})
+		if err != nil {
+			t.Errorf("join %s: %v", id.Hex(), err)
+			continue
+		}
+		if detail.Participants.RemainNumber != 1 {
+			t.Errorf("%s: %d seats left, want 1", detail.Name, detail.Participants.RemainNumber)
+		}
+	}
+}
+
+func TestMongoLeaveEventFreesSeatAfterFailedWrite(t *testing.T) {
+	f := newMongoFixture(t)
+	events := f.db.collection(mongoEventsCollection)
+	failing := map[string]bool{"$inc": true}
+	events.failUpdate = func(filter, update any) error {
+		if op, ok := update.(bson.D); ok && failing[op[0].Key] {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	detail, err := f.client.LeaveEvent(context.Background(), mongoSoonID.Hex(), mongoUserIDs[1].Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Participants.RemainNumber != 2 || len(detail.ParticipantList) != 3 {
+		t.Errorf("%d seats left, %d participants", detail.Participants.RemainNumber, len(detail.ParticipantList))
+	}
+
+	// When the seats cannot be counted again either, the error says the
+	// user has left all the same.
+	failing["$unset"] = true
+	_, err = f.client.LeaveEvent(context.Background(), mongoSoonID.Hex(), mongoUserIDs[2].Hex())
+	if err == nil || !strings.Contains(err.Error(), "left event") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestMongoSeatCountOfLegacyEvent(t *testing.T) {
+	f := newMongoFixture(t)
+	// An event written before the seat counter existed.
+	legacy := testObjectID(30)
+	f.db.collection(mongoEventsCollection).insert(bson.D{
+		{Key: "_id", Value: legacy},
+		{Key: "events_name", Value: "Legacy"},
+		{Key: "events_date", Value: f.now.Add(24 * time.Hour)},
+		{Key: "events_deadline", Value: f.now.Add(12 * time.Hour)},
+		{Key: "events_participant_limit", Value: 2},
+		{Key: "events_created_by", Value: mongoHostID},
+	})
+	f.db.collection(mongoParticipantsCollection).insert(bson.D{
+		{Key: "events_participants_event", Value: legacy},
+		{Key: "events_participants_user", Value: mongoUserIDs[0]},
+		{Key: "events_participants_created_at", Value: f.now},
+	})
+
+	if _, err := f.client.JoinEvent(context.Background(), legacy.Hex(), UserAgg{ID: mongoUserIDs[1].Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.client.JoinEvent(context.Background(), legacy.Hex(), UserAgg{ID: mongoUserIDs[2].Hex()}); !errors.Is(err, ErrEventFull) {
+		t.Errorf("err = %v, want ErrEventFull", err)
+	}
+}
+
+func TestMongoPeriodWithoutEnd(t *testing.T) {
+	client, db := newFakeMongoClient()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	at := func(hours int) time.Time { return now.Add(time.Duration(hours) * time.Hour) }
+
+	// Events without an end, as the memory store sees them too.
+	events := []Event{
+		{ID: testObjectID(1).Hex(), Date: NewTime(at(-2))},
+		{ID: testObjectID(2).Hex(), Date: NewTime(at(2))},
+		{ID: testObjectID(3).Hex(), Date: NewTime(at(-5)), DateEnd: NewTime(at(5))},
+		{ID: testObjectID(4).Hex()},
+	}
+	for _, e := range events {
+		id, _ := bson.ObjectIDFromHex(e.ID)
+		doc := bson.D{{Key: "_id", Value: id}}
+		if !e.Date.IsZero() {
+			doc = append(doc, bson.E{Key: "events_date", Value: e.Date.Time})
+		}
+		if !e.DateEnd.IsZero() {
+			doc = append(doc, bson.E{Key: "events_date_end", Value: e.DateEnd.Time})
+		}
+		db.collection(mongoEventsCollection).insert(doc)
+	}
+
+	yes, no := true, false
+	begin, end := at(-1), at(1)
+	filters := map[string]PeriodFilter{
+		"none":          {},
+		"past":          {Past: &yes},
+		"upcoming":      {Past: &no},
+		"begin":         {Begin: &begin},
+		"end":           {End: &end},
+		"begin and end": {Begin: &begin, End: &end},
+		"past with end": {Past: &yes, End: &end},
+	}
+	for name, period := range filters {
+		t.Run(name, func(t *testing.T) {
+			filter := EventFilter{PeriodFilter: period}
+			got, err := client.GetEvents(context.Background(), filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := eventIDs(filterEvents(events, filter, now))
+			ids := eventIDs(got)
+			slices.Sort(ids)
+			if !slices.Equal(ids, want) {
+				t.Errorf("mongo %v, memory %v", ids, want)
+			}
+		})
+	}
+}
+
+func TestMongoStoresMissingEnd(t *testing.T) {
+	f := newMongoFixture(t)
+	host := UserAgg{ID: mongoHostID.Hex()}
+	created, err := f.client.CreateEvent(context.Background(), EventDetail{Event: Event{
+		Name:             "Open end",
+		Date:             NewTime(f.now.Add(-time.Hour)),
+		Deadline:         NewTime(f.now.Add(-2 * time.Hour)),
+		ParticipantLimit: 5,
+		CreatedByUser:    &host,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasEnd := func(id string) bool {
+		oid, _ := bson.ObjectIDFromHex(id)
+		n, _ := f.db.collection(mongoEventsCollection).CountDocuments(context.Background(), bson.D{
+			{Key: "_id", Value: oid},
+			{Key: "events_date_end", Value: bson.D{{Key: "$exists", Value: true}}},
+		})
+		return n == 1
+	}
+	if hasEnd(created.ID) {
+		t.Error("event without an end was stored with one")
+	}
+
+	// It started an hour ago, so without an end it is over.
+	yes := true
+	past, _ := f.client.GetEvents(context.Background(), EventFilter{PeriodFilter: PeriodFilter{Past: &yes}})
+	if !slices.Contains(eventIDs(past), created.ID) {
+		t.Errorf("event without an end missing from past events %v", eventIDs(past))
+	}
+
+	soon, _ := f.client.GetEvent(context.Background(), mongoSoonID.Hex())
+	soon.DateEnd = Time{}
+	if _, err := f.client.UpdateEvent(context.Background(), *soon); err != nil {
+		t.Fatal(err)
+	}
+	if hasEnd(mongoSoonID.Hex()) {
+		t.Error("update kept the end that was removed")
+	}
+}
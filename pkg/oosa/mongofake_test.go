@@ -0,0 +1,990 @@
+package oosa
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// fakeMongo is an in-process stand-in for the OOSA database. Its collections
+// implement mongoCollection and evaluate the filters, updates and aggregation
+// stages MongoClient sends, so the client can be tested without a cluster.
+// Every operation holds the database lock, which makes single-document writes
+// atomic as they are in MongoDB. Anything the stand-in does not understand
+// panics, so that a new query shape cannot pass a test unevaluated.
+type fakeMongo struct {
+	mu          sync.Mutex
+	collections map[string]*fakeCollection
+}
+
+type fakeDoc = map[string]any
+
+func newFakeMongo() *fakeMongo {
+	return &fakeMongo{collections: make(map[string]*fakeCollection)}
+}
+
+// collection returns the named collection, creating it on first use.
+func (db *fakeMongo) collection(name string) *fakeCollection {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.collectionLocked(name)
+}
+
+func (db *fakeMongo) collectionLocked(name string) *fakeCollection {
+	c, ok := db.collections[name]
+	if !ok {
+		c = &fakeCollection{db: db, name: name}
+		db.collections[name] = c
+	}
+	return c
+}
+
+// newFakeMongoClient returns a MongoClient over a fresh fakeMongo, with the
+// indexes NewMongoClient creates.
+func newFakeMongoClient() (*MongoClient, *fakeMongo) {
+	db := newFakeMongo()
+	db.collection(mongoParticipantsCollection).createUniqueIndex(mongoParticipantKeys)
+	return &MongoClient{
+		events:       db.collection(mongoEventsCollection),
+		users:        db.collection(mongoUsersCollection),
+		ideas:        db.collection(mongoIdeasCollection),
+		participants: db.collection(mongoParticipantsCollection),
+		timeout:      time.Second,
+	}, db
+}
+
+type fakeCollection struct {
+	db   *fakeMongo
+	name string
+	docs []fakeDoc
+	// unique holds the keys of the unique indexes of the collection.
+	unique [][]string
+	// racyUpserts lets other writes in between the search and the insert of
+	// an upsert, as happens on a real server.
+	racyUpserts bool
+	// failUpdate, when set, is asked before every UpdateOne and fails it by
+	// returning an error, as a lost connection would.
+	failUpdate func(filter, update any) error
+}
+
+// createUniqueIndex makes the collection reject a second document with the
+// same values for keys, like a unique index.
+func (c *fakeCollection) createUniqueIndex(keys bson.D) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	var fields []string
+	for _, k := range keys {
+		fields = append(fields, k.Key)
+	}
+	c.unique = append(c.unique, fields)
+}
+
+// insert adds documents to the collection as they are, for test fixtures.
+func (c *fakeCollection) insert(docs ...any) {
+	for _, doc := range docs {
+		if _, err := c.InsertOne(context.Background(), doc); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (c *fakeCollection) Aggregate(ctx context.Context, pipeline any, opts ...options.Lister[options.AggregateOptions]) (*mongo.Cursor, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	out := c.db.aggregate(c.snapshot(), fakePipeline(pipeline), nil)
+	return mongo.NewCursorFromDocuments(fakeDocuments(out), nil, nil)
+}
+
+func (c *fakeCollection) Find(ctx context.Context, filter any, opts ...options.Lister[options.FindOptions]) (*mongo.Cursor, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	var out []fakeDoc
+	for _, doc := range c.snapshot() {
+		if c.db.match(doc, filter, nil) {
+			out = append(out, doc)
+		}
+	}
+	return mongo.NewCursorFromDocuments(fakeDocuments(out), nil, nil)
+}
+
+func (c *fakeCollection) FindOne(ctx context.Context, filter any, opts ...options.Lister[options.FindOneOptions]) *mongo.SingleResult {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, doc := range c.snapshot() {
+		if c.db.match(doc, filter, nil) {
+			return mongo.NewSingleResultFromDocument(doc, nil, nil)
+		}
+	}
+	return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+}
+
+func (c *fakeCollection) CountDocuments(ctx context.Context, filter any, opts ...options.Lister[options.CountOptions]) (int64, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	var n int64
+	for _, doc := range c.docs {
+		if c.db.match(doc, filter, nil) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *fakeCollection) InsertOne(ctx context.Context, document any, opts ...options.Lister[options.InsertOneOptions]) (*mongo.InsertOneResult, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	doc, ok := fakeValue(document).(fakeDoc)
+	if !ok {
+		panic(fmt.Sprintf("fakeMongo: cannot insert %T", document))
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = bson.NewObjectID()
+	}
+	if err := c.checkUnique(doc, -1); err != nil {
+		return nil, err
+	}
+	c.docs = append(c.docs, doc)
+	return &mongo.InsertOneResult{InsertedID: doc["_id"], Acknowledged: true}, nil
+}
+
+func (c *fakeCollection) UpdateOne(ctx context.Context, filter any, update any, opts ...options.Lister[options.UpdateOneOptions]) (*mongo.UpdateResult, error) {
+	var o options.UpdateOneOptions
+	for _, l := range opts {
+		for _, set := range l.List() {
+			if err := set(&o); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.failUpdate != nil {
+		if err := c.failUpdate(filter, update); err != nil {
+			return nil, err
+		}
+	}
+	for i, doc := range c.docs {
+		if !c.db.match(doc, filter, nil) {
+			continue
+		}
+		updated := fakeClone(doc).(fakeDoc)
+		fakeApplyUpdate(updated, update, false)
+		if err := c.checkUnique(updated, i); err != nil {
+			return nil, err
+		}
+		c.docs[i] = updated
+		return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1, Acknowledged: true}, nil
+	}
+
+	if o.Upsert == nil || !*o.Upsert {
+		return &mongo.UpdateResult{Acknowledged: true}, nil
+	}
+	if c.racyUpserts {
+		c.db.mu.Unlock()
+		runtime.Gosched()
+		c.db.mu.Lock()
+	}
+	doc := fakeDoc{}
+	for _, e := range fakeElems(filter) {
+		if _, isOp := fakeOperators(e.Value); !isOp && !strings.HasPrefix(e.Key, "$") {
+			fakeSet(doc, e.Key, fakeValue(e.Value))
+		}
+	}
+	fakeApplyUpdate(doc, update, true)
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = bson.NewObjectID()
+	}
+	if err := c.checkUnique(doc, -1); err != nil {
+		return nil, err
+	}
+	c.docs = append(c.docs, doc)
+	return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: doc["_id"], Acknowledged: true}, nil
+}
+
+func (c *fakeCollection) DeleteOne(ctx context.Context, filter any, opts ...options.Lister[options.DeleteOneOptions]) (*mongo.DeleteResult, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for i, doc := range c.docs {
+		if c.db.match(doc, filter, nil) {
+			c.docs = slices.Delete(c.docs, i, i+1)
+			return &mongo.DeleteResult{DeletedCount: 1, Acknowledged: true}, nil
+		}
+	}
+	return &mongo.DeleteResult{Acknowledged: true}, nil
+}
+
+// snapshot returns deep copies of the documents, for stages to rewrite.
+// Callers must hold db.mu.
+func (c *fakeCollection) snapshot() []fakeDoc {
+	docs := make([]fakeDoc, len(c.docs))
+	for i, doc := range c.docs {
+		docs[i] = fakeClone(doc).(fakeDoc)
+	}
+	return docs
+}
+
+// checkUnique fails like a duplicate key when doc collides with another
+// document than the one at skip on a unique index. Callers must hold db.mu.
+func (c *fakeCollection) checkUnique(doc fakeDoc, skip int) error {
+	for _, keys := range c.unique {
+		for i, other := range c.docs {
+			if i == skip {
+				continue
+			}
+			same := true
+			for _, k := range keys {
+				a, _ := fakeGet(doc, k)
+				b, _ := fakeGet(other, k)
+				if !fakeEqual(a, b) {
+					same = false
+					break
+				}
+			}
+			if same {
+				return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+					Code:    11000,
+					Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s", c.name, strings.Join(keys, "_")),
+				}}}
+			}
+		}
+	}
+	return nil
+}
+
+// fakeValue converts a Go value into the form documents are kept in, by a
+// round trip through BSON: documents become fakeDoc, arrays []any and
+// integers int64.
+func fakeValue(v any) any {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		panic(fmt.Sprintf("fakeMongo: %v", err))
+	}
+	var d bson.D
+	if err := bson.Unmarshal(data, &d); err != nil {
+		panic(fmt.Sprintf("fakeMongo: %v", err))
+	}
+	return fakeNormalize(d[0].Value)
+}
+
+func fakeNormalize(v any) any {
+	switch v := v.(type) {
+	case bson.D:
+		doc := make(fakeDoc, len(v))
+		for _, e := range v {
+			doc[e.Key] = fakeNormalize(e.Value)
+		}
+		return doc
+	case bson.M:
+		doc := make(fakeDoc, len(v))
+		for k, e := range v {
+			doc[k] = fakeNormalize(e)
+		}
+		return doc
+	case bson.A:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = fakeNormalize(e)
+		}
+		return a
+	case int32:
+		return int64(v)
+	}
+	return v
+}
+
+func fakeClone(v any) any {
+	switch v := v.(type) {
+	case fakeDoc:
+		doc := make(fakeDoc, len(v))
+		for k, e := range v {
+			doc[k] = fakeClone(e)
+		}
+		return doc
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = fakeClone(e)
+		}
+		return a
+	}
+	return v
+}
+
+func fakeDocuments(docs []fakeDoc) []any {
+	out := make([]any, len(docs))
+	for i, doc := range docs {
+		out[i] = doc
+	}
+	return out
+}
+
+// fakeElems returns the fields of a filter, update or stage document.
+func fakeElems(v any) bson.D {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bson.D:
+		return v
+	case bson.M:
+		d := make(bson.D, 0, len(v))
+		for k, e := range v {
+			d = append(d, bson.E{Key: k, Value: e})
+		}
+		slices.SortFunc(d, func(a, b bson.E) int { return strings.Compare(a.Key, b.Key) })
+		return d
+	}
+	panic(fmt.Sprintf("fakeMongo: expected a document, got %T", v))
+}
+
+// fakeOperators returns the operators of a field condition such as
+// {$gte: x, $lt: y}, or false for a plain value.
+func fakeOperators(v any) (bson.D, bool) {
+	d, ok := v.(bson.D)
+	if !ok || len(d) == 0 {
+		return nil, false
+	}
+	for _, e := range d {
+		if !strings.HasPrefix(e.Key, "$") {
+			return nil, false
+		}
+	}
+	return d, true
+}
+
+func fakePipeline(v any) []bson.D {
+	switch v := v.(type) {
+	case mongo.Pipeline:
+		return v
+	case []bson.D:
+		return v
+	case bson.A:
+		stages := make([]bson.D, len(v))
+		for i, s := range v {
+			stages[i] = s.(bson.D)
+		}
+		return stages
+	}
+	panic(fmt.Sprintf("fakeMongo: expected a pipeline, got %T", v))
+}
+
+func fakeGet(v any, path string) (any, bool) {
+	for _, part := range strings.Split(path, ".") {
+		doc, ok := v.(fakeDoc)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = doc[part]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func fakeSet(doc fakeDoc, path string, v any) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(fakeDoc)
+		if !ok {
+			next = fakeDoc{}
+			doc[part] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = v
+}
+
+func fakeUnset(doc fakeDoc, path string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(fakeDoc)
+		if !ok {
+			return
+		}
+		doc = next
+	}
+	delete(doc, parts[len(parts)-1])
+}
+
+func fakeNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// fakeTypeOrder ranks values of different types the way MongoDB sorts them.
+func fakeTypeOrder(v any) int {
+	switch v.(type) {
+	case nil:
+		return 1
+	case int64, int, float64:
+		return 2
+	case string:
+		return 3
+	case fakeDoc:
+		return 4
+	case []any:
+		return 5
+	case bson.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case bson.DateTime:
+		return 9
+	case bson.Regex:
+		return 11
+	}
+	panic(fmt.Sprintf("fakeMongo: cannot compare %T", v))
+}
+
+// fakeCompare orders any two values, across types by fakeTypeOrder.
+func fakeCompare(a, b any) int {
+	if ta, tb := fakeTypeOrder(a), fakeTypeOrder(b); ta != tb {
+		return cmp.Compare(ta, tb)
+	}
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case bson.ObjectID:
+		bb := b.(bson.ObjectID)
+		return bytes.Compare(a[:], bb[:])
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case a:
+			return 1
+		}
+		return -1
+	case bson.DateTime:
+		return cmp.Compare(a, b.(bson.DateTime))
+	case nil:
+		return 0
+	}
+	if fa, ok := fakeNumber(a); ok {
+		fb, _ := fakeNumber(b)
+		return cmp.Compare(fa, fb)
+	}
+	panic(fmt.Sprintf("fakeMongo: cannot compare %T", a))
+}
+
+// fakeComparable reports whether a query comparison such as $lt applies to
+// a and b: MongoDB only compares values of the same type there.
+func fakeComparable(a, b any) bool {
+	return fakeTypeOrder(a) == fakeTypeOrder(b)
+}
+
+func fakeEqual(a, b any) bool {
+	if fakeTypeOrder(a) != fakeTypeOrder(b) {
+		return false
+	}
+	switch a.(type) {
+	case fakeDoc, []any, bson.Regex:
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return fakeCompare(a, b) == 0
+}
+
+// fakeMatchValue reports whether a stored value matches an $in or equality
+// operand, which may be a regular expression.
+func fakeMatchValue(v, operand any) bool {
+	if re, ok := operand.(bson.Regex); ok {
+		s, ok := v.(string)
+		return ok && fakeRegexp(re).MatchString(s)
+	}
+	return fakeEqual(v, operand)
+}
+
+func fakeRegexp(re bson.Regex) *regexp.Regexp {
+	pattern := re.Pattern
+	if re.Options != "" {
+		pattern = "(?" + re.Options + ")" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+func fakeTruthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	if n, ok := fakeNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+// match evaluates a query filter against doc. vars are the variables of an
+// enclosing $lookup, for $expr.
+func (db *fakeMongo) match(doc fakeDoc, filter any, vars fakeDoc) bool {
+	for _, e := range fakeElems(filter) {
+		switch e.Key {
+		case "$expr":
+			if !fakeTruthy(db.eval(doc, e.Value, vars)) {
+				return false
+			}
+		case "$and", "$or":
+			matched := false
+			for _, sub := range e.Value.(bson.A) {
+				ok := db.match(doc, sub, vars)
+				if e.Key == "$and" && !ok {
+					return false
+				}
+				matched = matched || ok
+			}
+			if e.Key == "$or" && !matched {
+				return false
+			}
+		default:
+			v, exists := fakeGet(doc, e.Key)
+			ops, isOp := fakeOperators(e.Value)
+			if !isOp {
+				if !fakeMatchValue(v, fakeValue(e.Value)) {
+					return false
+				}
+				continue
+			}
+			for _, op := range ops {
+				if !fakeApplyOperator(v, exists, op) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func fakeApplyOperator(v any, exists bool, op bson.E) bool {
+	operand := fakeValue(op.Value)
+	switch op.Key {
+	case "$eq":
+		return fakeMatchValue(v, operand)
+	case "$ne":
+		return !fakeMatchValue(v, operand)
+	case "$lt", "$lte", "$gt", "$gte":
+		if !exists || !fakeComparable(v, operand) {
+			return false
+		}
+		c := fakeCompare(v, operand)
+		switch op.Key {
+		case "$lt":
+			return c < 0
+		case "$lte":
+			return c <= 0
+		case "$gt":
+			return c > 0
+		}
+		return c >= 0
+	case "$in", "$nin":
+		in := false
+		for _, o := range operand.([]any) {
+			if fakeMatchValue(v, o) {
+				in = true
+				break
+			}
+		}
+		return in == (op.Key == "$in")
+	case "$exists":
+		return exists == fakeTruthy(operand)
+	}
+	panic(fmt.Sprintf("fakeMongo: unsupported query operator %s", op.Key))
+}
+
+// fakeApplyUpdate applies the update operators to doc. $setOnInsert only
+// applies when the update inserts doc.
+func fakeApplyUpdate(doc fakeDoc, update any, inserting bool) {
+	for _, e := range fakeElems(update) {
+		switch e.Key {
+		case "$set":
+			for _, f := range fakeElems(e.Value) {
+				fakeSet(doc, f.Key, fakeValue(f.Value))
+			}
+		case "$setOnInsert":
+			if !inserting {
+				continue
+			}
+			for _, f := range fakeElems(e.Value) {
+				fakeSet(doc, f.Key, fakeValue(f.Value))
+			}
+		case "$unset":
+			for _, f := range fakeElems(e.Value) {
+				fakeUnset(doc, f.Key)
+			}
+		case "$inc":
+			for _, f := range fakeElems(e.Value) {
+				by := fakeValue(f.Value)
+				old, _ := fakeGet(doc, f.Key)
+				if old == nil {
+					fakeSet(doc, f.Key, by)
+					continue
+				}
+				if i, ok := old.(int64); ok {
+					if j, ok := by.(int64); ok {
+						fakeSet(doc, f.Key, i+j)
+						continue
+					}
+				}
+				x, _ := fakeNumber(old)
+				y, _ := fakeNumber(by)
+				fakeSet(doc, f.Key, x+y)
+			}
+		default:
+			panic(fmt.Sprintf("fakeMongo: unsupported update operator %s", e.Key))
+		}
+	}
+}
+
+// eval evaluates an aggregation expression against doc.
+func (db *fakeMongo) eval(doc fakeDoc, expr any, vars fakeDoc) any {
+	switch x := expr.(type) {
+	case string:
+		if name, ok := strings.CutPrefix(x, "$$"); ok {
+			name, path, _ := strings.Cut(name, ".")
+			v := vars[name]
+			if path != "" {
+				v, _ = fakeGet(v, path)
+			}
+			return v
+		}
+		if path, ok := strings.CutPrefix(x, "$"); ok {
+			return fakeGetPath(doc, path)
+		}
+		return x
+	case bson.A:
+		a := make([]any, len(x))
+		for i, e := range x {
+			a[i] = db.eval(doc, e, vars)
+		}
+		return a
+	case bson.D:
+		if len(x) == 1 && strings.HasPrefix(x[0].Key, "$") {
+			return db.evalOperator(doc, x[0].Key, x[0].Value, vars)
+		}
+		out := fakeDoc{}
+		for _, e := range x {
+			out[e.Key] = db.eval(doc, e.Value, vars)
+		}
+		return out
+	}
+	return fakeValue(expr)
+}
+
+// fakeGetPath resolves a field path like $events_participants.total; over an
+// array it collects the field of every element, as MongoDB does.
+func fakeGetPath(v any, path string) any {
+	head, rest, nested := strings.Cut(path, ".")
+	switch v := v.(type) {
+	case fakeDoc:
+		field, ok := v[head]
+		if !ok {
+			return nil
+		}
+		if !nested {
+			return field
+		}
+		return fakeGetPath(field, rest)
+	case []any:
+		var out []any
+		for _, e := range v {
+			if field := fakeGetPath(e, path); field != nil {
+				out = append(out, field)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (db *fakeMongo) evalOperator(doc fakeDoc, op string, arg any, vars fakeDoc) any {
+	args := func() []any {
+		if a, ok := arg.(bson.A); ok {
+			return db.eval(doc, a, vars).([]any)
+		}
+		return []any{db.eval(doc, arg, vars)}
+	}
+	switch op {
+	case "$eq", "$ne", "$lt", "$lte", "$gt", "$gte":
+		a := args()
+		c := fakeCompare(a[0], a[1])
+		switch op {
+		case "$eq":
+			return c == 0
+		case "$ne":
+			return c != 0
+		case "$lt":
+			return c < 0
+		case "$lte":
+			return c <= 0
+		case "$gt":
+			return c > 0
+		}
+		return c >= 0
+	case "$and":
+		for _, v := range args() {
+			if !fakeTruthy(v) {
+				return false
+			}
+		}
+		return true
+	case "$or":
+		for _, v := range args() {
+			if fakeTruthy(v) {
+				return true
+			}
+		}
+		return false
+	case "$not":
+		return !fakeTruthy(args()[0])
+	case "$max":
+		var max any
+		for _, v := range args() {
+			if v != nil && (max == nil || fakeCompare(v, max) > 0) {
+				max = v
+			}
+		}
+		return max
+	case "$subtract":
+		a := args()
+		x, okx := fakeNumber(a[0])
+		y, oky := fakeNumber(a[1])
+		if !okx || !oky {
+			return nil
+		}
+		if ix, ok := a[0].(int64); ok {
+			if iy, ok := a[1].(int64); ok {
+				return ix - iy
+			}
+		}
+		return x - y
+	case "$ifNull":
+		for _, v := range args() {
+			if v != nil {
+				return v
+			}
+		}
+		return nil
+	case "$arrayElemAt":
+		a := args()
+		arr, _ := a[0].([]any)
+		i, _ := fakeNumber(a[1])
+		if int(i) < 0 || int(i) >= len(arr) {
+			return nil
+		}
+		return arr[int(i)]
+	case "$cond":
+		a := args()
+		if fakeTruthy(a[0]) {
+			return a[1]
+		}
+		return a[2]
+	}
+	panic(fmt.Sprintf("fakeMongo: unsupported expression operator %s", op))
+}
+
+// aggregate runs the stages of a pipeline over docs. Callers must hold db.mu.
+func (db *fakeMongo) aggregate(docs []fakeDoc, pipeline []bson.D, vars fakeDoc) []fakeDoc {
+	for _, stage := range pipeline {
+		if len(stage) != 1 {
+			panic(fmt.Sprintf("fakeMongo: a stage has exactly one field, got %v", stage))
+		}
+		spec := stage[0].Value
+		switch stage[0].Key {
+		case "$match":
+			kept := docs[:0]
+			for _, doc := range docs {
+				if db.match(doc, spec, vars) {
+					kept = append(kept, doc)
+				}
+			}
+			docs = kept
+		case "$sort":
+			keys := fakeElems(spec)
+			slices.SortStableFunc(docs, func(a, b fakeDoc) int {
+				for _, k := range keys {
+					va, _ := fakeGet(a, k.Key)
+					vb, _ := fakeGet(b, k.Key)
+					if c := fakeCompare(va, vb); c != 0 {
+						if dir, _ := fakeNumber(fakeValue(k.Value)); dir < 0 {
+							return -c
+						}
+						return c
+					}
+				}
+				return 0
+			})
+		case "$limit":
+			n, _ := fakeNumber(fakeValue(spec))
+			docs = docs[:min(len(docs), int(n))]
+		case "$skip":
+			n, _ := fakeNumber(fakeValue(spec))
+			docs = docs[min(len(docs), int(n)):]
+		case "$lookup":
+			docs = db.lookup(docs, fakeElems(spec), vars)
+		case "$unwind":
+			docs = fakeUnwind(docs, spec)
+		case "$set", "$addFields":
+			for _, doc := range docs {
+				orig := fakeClone(doc).(fakeDoc)
+				for _, e := range fakeElems(spec) {
+					fakeSet(doc, e.Key, db.eval(orig, e.Value, vars))
+				}
+			}
+		case "$unset":
+			fields, ok := spec.(bson.A)
+			if !ok {
+				fields = bson.A{spec}
+			}
+			for _, doc := range docs {
+				for _, f := range fields {
+					fakeUnset(doc, f.(string))
+				}
+			}
+		case "$project":
+			for i, doc := range docs {
+				docs[i] = db.project(doc, fakeElems(spec), vars)
+			}
+		case "$replaceRoot":
+			for i, doc := range docs {
+				root, ok := db.eval(doc, fakeElems(spec)[0].Value, vars).(fakeDoc)
+				if !ok {
+					panic("fakeMongo: $replaceRoot needs a document")
+				}
+				docs[i] = root
+			}
+		case "$facet":
+			out := fakeDoc{}
+			for _, e := range fakeElems(spec) {
+				input := make([]fakeDoc, len(docs))
+				for i, doc := range docs {
+					input[i] = fakeClone(doc).(fakeDoc)
+				}
+				out[e.Key] = fakeDocuments(db.aggregate(input, fakePipeline(e.Value), vars))
+			}
+			docs = []fakeDoc{out}
+		case "$count":
+			if len(docs) == 0 {
+				break
+			}
+			docs = []fakeDoc{{spec.(string): int64(len(docs))}}
+		default:
+			panic(fmt.Sprintf("fakeMongo: unsupported stage %s", stage[0].Key))
+		}
+	}
+	return docs
+}
+
+func (db *fakeMongo) lookup(docs []fakeDoc, spec bson.D, vars fakeDoc) []fakeDoc {
+	opts := fakeDoc{}
+	for _, e := range spec {
+		opts[e.Key] = e.Value
+	}
+	from := db.collectionLocked(opts["from"].(string))
+	as := opts["as"].(string)
+
+	for _, doc := range docs {
+		var joined []fakeDoc
+		if local, ok := opts["localField"].(string); ok {
+			v, _ := fakeGet(doc, local)
+			for _, other := range from.snapshot() {
+				w, _ := fakeGet(other, opts["foreignField"].(string))
+				if fakeEqual(v, w) {
+					joined = append(joined, other)
+				}
+			}
+		} else {
+			inner := fakeDoc{}
+			for k, v := range vars {
+				inner[k] = v
+			}
+			for _, e := range fakeElems(opts["let"]) {
+				inner[e.Key] = db.eval(doc, e.Value, vars)
+			}
+			joined = db.aggregate(from.snapshot(), fakePipeline(opts["pipeline"]), inner)
+		}
+		doc[as] = fakeDocuments(joined)
+	}
+	return docs
+}
+
+func fakeUnwind(docs []fakeDoc, spec any) []fakeDoc {
+	path, preserve := "", false
+	if s, ok := spec.(string); ok {
+		path = s
+	} else {
+		for _, e := range fakeElems(spec) {
+			switch e.Key {
+			case "path":
+				path = e.Value.(string)
+			case "preserveNullAndEmptyArrays":
+				preserve = e.Value.(bool)
+			}
+		}
+	}
+	field := strings.TrimPrefix(path, "$")
+
+	var out []fakeDoc
+	for _, doc := range docs {
+		v, _ := fakeGet(doc, field)
+		arr, isArray := v.([]any)
+		switch {
+		case isArray && len(arr) > 0:
+			for _, e := range arr {
+				d := fakeClone(doc).(fakeDoc)
+				fakeSet(d, field, fakeClone(e))
+				out = append(out, d)
+			}
+		case !isArray && v != nil:
+			out = append(out, doc)
+		case preserve:
+			if isArray {
+				fakeUnset(doc, field)
+			}
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+func (db *fakeMongo) project(doc fakeDoc, spec bson.D, vars fakeDoc) fakeDoc {
+	out := fakeDoc{}
+	if id, ok := doc["_id"]; ok {
+		out["_id"] = id
+	}
+	for _, e := range spec {
+		switch v := fakeValue(e.Value).(type) {
+		case bool, int64, float64:
+			if !fakeTruthy(v) {
+				if e.Key != "_id" {
+					panic("fakeMongo: exclusion projections are not supported")
+				}
+				delete(out, "_id")
+				continue
+			}
+			if field, ok := fakeGet(doc, e.Key); ok {
+				fakeSet(out, e.Key, field)
+			}
+		default:
+			fakeSet(out, e.Key, db.eval(doc, e.Value, vars))
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package oosa
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// cursorKey is the position of an item in a listing. Items are ordered by
+// Time and then ID, so a cursor pointing just past the last item of a page
+// stays valid when items are inserted before or after it.
+type cursorKey struct {
+	Time int64  `json:"t"`
+	ID   string `json:"i"`
+}
+
+func (k cursorKey) less(o cursorKey) bool {
+	if k.Time != o.Time {
+		return k.Time < o.Time
+	}
+	return k.ID < o.ID
+}
+
+// encodeCursor turns a key into the opaque cursor handed to clients.
+func encodeCursor(k cursorKey) string {
+	data, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+func decodeCursor(s string) (cursorKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursorKey{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	var k cursorKey
+	if err := json.Unmarshal(data, &k); err != nil || k.ID == "" {
+		return cursorKey{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	return k, nil
+}
+
+// paginate orders items by key and returns the page that follows p.Cursor,
+// the cursor of the next page and whether there is one.
+func paginate[T any](items []T, key func(T) cursorKey, p PaginationParams) ([]T, string, bool, error) {
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]).less(key(sorted[j]))
+	})
+
+	start := 0
+	if p.Cursor != "" {
+		after, err := decodeCursor(p.Cursor)
+		if err != nil {
+			return nil, "", false, err
+		}
+		start = sort.Search(len(sorted), func(i int) bool {
+			return after.less(key(sorted[i]))
+		})
+	}
+
+	end := start + p.PerPage
+	if end >= len(sorted) {
+		return sorted[start:], "", false, nil
+	}
+	return sorted[start:end], encodeCursor(key(sorted[end-1])), true, nil
+}
+
+// EventsPage is one page of a get_events listing.
+type EventsPage struct {
+	Events     []Event `json:"events"`
+	TotalCount int     `json:"total_count"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	HasMore    bool    `json:"has_more"`
+}
+
+// eventCursorKey orders events by start time, then ID.
+func eventCursorKey(e Event) cursorKey {
+	var t int64
+	if !e.Date.IsZero() {
+		t = e.Date.UnixNano()
+	}
+	return cursorKey{Time: t, ID: e.ID}
+}
+
+// paginateEvents returns the page of events selected by p.
+func paginateEvents(events []Event, p PaginationParams) (EventsPage, error) {
+	page, next, hasMore, err := paginate(events, eventCursorKey, p)
+	if err != nil {
+		return EventsPage{}, err
+	}
+	return EventsPage{
+		Events:     page,
+		TotalCount: len(events),
+		NextCursor: next,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// IdeasPage is one page of a get_ideas listing.
+type IdeasPage struct {
+	Ideas      []Idea `json:"ideas"`
+	TotalCount int    `json:"total_count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// ideaCursorKey orders ideas by proposed date, then ID.
+func ideaCursorKey(i Idea) cursorKey {
+	var t int64
+	if !i.Date.IsZero() {
+		t = i.Date.UnixNano()
+	}
+	return cursorKey{Time: t, ID: i.ID}
+}
+
+// paginateIdeas returns the page of ideas selected by p.
+func paginateIdeas(ideas []Idea, p PaginationParams) (IdeasPage, error) {
+	page, next, hasMore, err := paginate(ideas, ideaCursorKey, p)
+	if err != nil {
+		return IdeasPage{}, err
+	}
+	return IdeasPage{
+		Ideas:      page,
+		TotalCount: len(ideas),
+		NextCursor: next,
+		HasMore:    hasMore,
+	}, nil
+}
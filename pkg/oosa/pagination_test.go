@@ -0,0 +1,124 @@
+package oosa
+
+import (
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pageIDs lists the IDs of the events of page.
+func pageIDs(page EventsPage) []string {
+	var ids []string
+	for _, e := range page.Events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// getEventsPage asks get_events for the page after cursor, two events long.
+func getEventsPage(t *testing.T, client *testClient, cursor string) EventsPage {
+	t.Helper()
+	args := map[string]any{"perPage": 2.0, "format": "json"}
+	if cursor != "" {
+		args["cursor"] = cursor
+	}
+	var page EventsPage
+	client.callTool("get_events", args, &page)
+	return page
+}
+
+func TestCursorsSurviveInserts(t *testing.T) {
+	backend := newOutsideBackend()
+	client := newTestClient(t, backend)
+
+	page := getEventsPage(t, client, "")
+	if ids := pageIDs(page); !slices.Equal(ids, []string{"event1", "event2"}) || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("first page %v, has_more %v, next_cursor %q", ids, page.HasMore, page.NextCursor)
+	}
+
+	// One event lands on the page already read, one on a page still to come.
+	backend.insert(t, Event{Name: "早鳥", Date: mustParseTime("2025-04-10T00:00:00Z")})
+	later := backend.insert(t, Event{Name: "晚到", Date: mustParseTime("2025-04-13T20:00:00Z")})
+
+	seen := []string{"event1", "event2"}
+	for cursor := page.NextCursor; cursor != ""; cursor = page.NextCursor {
+		page = getEventsPage(t, client, cursor)
+		seen = append(seen, pageIDs(page)...)
+		if page.TotalCount != 7 {
+			t.Errorf("total_count %d, want 7", page.TotalCount)
+		}
+	}
+	if want := []string{"event1", "event2", "event3", later.ID, "event4", "event5"}; !slices.Equal(seen, want) {
+		t.Errorf("read %v, want %v", seen, want)
+	}
+}
+
+func TestLastPage(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+
+	page := getEventsPage(t, client, "")
+	var cursor string
+	for page.HasMore {
+		cursor = page.NextCursor
+		page = getEventsPage(t, client, cursor)
+	}
+	if ids := pageIDs(page); !slices.Equal(ids, []string{"event5"}) || page.NextCursor != "" {
+		t.Errorf("last page %v, next_cursor %q", ids, page.NextCursor)
+	}
+	// The last page says so and leaves next_cursor out.
+	text, _ := client.tryTool("get_events", map[string]any{"perPage": 2.0, "format": "json", "cursor": cursor})
+	if !strings.Contains(text, `"has_more": false`) || strings.Contains(text, "next_cursor") {
+		t.Errorf("last page as sent:\n%s", text)
+	}
+
+	// A page that exactly fills up the rest is the last one too.
+	events := []Event{mockEvent(t, "event1").Event, mockEvent(t, "event2").Event}
+	full, err := paginateEvents(events, PaginationParams{PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Events) != 2 || full.HasMore || full.NextCursor != "" {
+		t.Errorf("full page: %d events, has_more %v, next_cursor %q", len(full.Events), full.HasMore, full.NextCursor)
+	}
+	after, err := paginateEvents(events, PaginationParams{PerPage: 2, Cursor: encodeCursor(eventCursorKey(events[1]))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Events) != 0 || after.HasMore || after.TotalCount != 2 {
+		t.Errorf("past the end: %+v", after)
+	}
+}
+
+func TestCursorTies(t *testing.T) {
+	// Events starting together are told apart by ID.
+	at := NewTime(time.Date(2025, 4, 11, 16, 0, 0, 0, time.UTC))
+	events := []Event{{ID: "c", Date: at}, {ID: "a", Date: at}, {ID: "b", Date: at}}
+	first, err := paginateEvents(events, PaginationParams{PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := paginateEvents(events, PaginationParams{PerPage: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := append(pageIDs(first), pageIDs(second)...); !slices.Equal(ids, []string{"a", "b", "c"}) {
+		t.Errorf("read %v", ids)
+	}
+}
+
+func TestMalformedCursor(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	for _, cursor := range []string{
+		"not a cursor",
+		base64.RawURLEncoding.EncodeToString([]byte("event2")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"t":1744473600000000000}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2025-04-12","i":"event2"}`)),
+	} {
+		text, isError := client.tryTool("get_events", map[string]any{"cursor": cursor})
+		if !isError || !strings.Contains(text, `"code":"invalid_argument"`) || !strings.Contains(text, "invalid cursor") {
+			t.Errorf("cursor %q: %s", cursor, text)
+		}
+	}
+}
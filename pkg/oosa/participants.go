@@ -0,0 +1,83 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// eventIDArgs are the arguments of the tools that act on one event.
+type eventIDArgs struct {
+	EventID string `arg:"events_id,required" desc:"The ID of the event"`
+}
+
+// eventChangeResult turns the outcome of an EventWriter call into a tool result.
+func eventChangeResult(action, eventID string, event *EventDetail, err error, output OutputParams) (*mcp.CallToolResult, error) {
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return eventNotFound(eventID).Result(), nil
+		}
+		if refusal := eventRefusal(action, eventID, err); refusal != nil {
+			return refusal.Result(), nil
+		}
+		return backendError(err, "failed to "+action+" event", "無法"+actionsZH[action]+"活動").Result(), nil
+	}
+
+	return renderResult(event, output)
+}
+
+func JoinEvent(store EventWriter, users UserStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("join_event",
+			mcp.WithDescription("Join an OOSA event as the authenticated user, taking one of its remaining seats. Fails when the registration deadline has passed, the event is full or the user already joined"),
+			WithArgs(eventIDArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args eventIDArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			user, err := callerUser(ctx, users)
+			if err != nil {
+				return callerError(err).Result(), nil
+			}
+
+			event, err := store.JoinEvent(ctx, args.EventID, *user)
+			return eventChangeResult("join", args.EventID, event, err, output)
+		}
+}
+
+func LeaveEvent(store EventWriter, users UserStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("leave_event",
+			mcp.WithDescription("Leave an OOSA event the authenticated user joined, freeing their seat. Not possible once the event has started"),
+			WithArgs(eventIDArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args eventIDArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			user, err := callerUser(ctx, users)
+			if err != nil {
+				return callerError(err).Result(), nil
+			}
+
+			event, err := store.LeaveEvent(ctx, args.EventID, user.ID)
+			return eventChangeResult("leave", args.EventID, event, err, output)
+		}
+}
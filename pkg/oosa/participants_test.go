@@ -0,0 +1,145 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// joinConcurrently has every user in userIDs join eventID at once, and
+// returns the users let in and how often each error came back.
+func joinConcurrently(t *testing.T, store EventWriter, eventID string, userIDs []string) (joined map[string]int, refused map[error]int) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+	joined, refused = make(map[string]int), make(map[error]int)
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := store.JoinEvent(context.Background(), eventID, UserAgg{ID: userID})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				joined[userID]++
+			case errors.Is(err, ErrEventFull):
+				refused[ErrEventFull]++
+			case errors.Is(err, ErrAlreadyJoined):
+				refused[ErrAlreadyJoined]++
+			default:
+				t.Errorf("join by %s: %v", userID, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return joined, refused
+}
+
+// checkJoins checks that exactly want users got in, each once, and that
+// every other attempt was refused.
+func checkJoins(t *testing.T, attempts, want int, joined map[string]int, refused map[error]int) {
+	t.Helper()
+	if len(joined) != want {
+		t.Errorf("%d users joined, want %d", len(joined), want)
+	}
+	for userID, n := range joined {
+		if n != 1 {
+			t.Errorf("%s joined %d times", userID, n)
+		}
+	}
+	if n := len(joined) + refused[ErrEventFull] + refused[ErrAlreadyJoined]; n != attempts {
+		t.Errorf("%d of %d joins accounted for", n, attempts)
+	}
+}
+
+func TestConcurrentJoins(t *testing.T) {
+	const limit, users = 50, 300
+	future := time.Now().Add(48 * time.Hour)
+
+	// Every user tries twice, so both the seat limit and repeated joins race.
+	var hexUsers, memoryUsers []string
+	for range users {
+		id := bson.NewObjectID().Hex()
+		hexUsers = append(hexUsers, id, id)
+		memoryUsers = append(memoryUsers, "m"+id, "m"+id)
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		store := newMemoryStore(nil, []Event{{
+			ID: "e1", Name: "Event", Date: NewTime(future), DateEnd: NewTime(future.Add(time.Hour)),
+			Deadline: NewTime(future.Add(-time.Hour)), ParticipantLimit: limit,
+		}}, nil)
+		joined, refused := joinConcurrently(t, store, "e1", memoryUsers)
+		checkJoins(t, len(memoryUsers), limit, joined, refused)
+
+		event, err := store.GetEvent(context.Background(), "e1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(event.ParticipantList) != limit || event.Participants.RemainNumber != 0 {
+			t.Errorf("%d participants, %d seats left", len(event.ParticipantList), event.Participants.RemainNumber)
+		}
+	})
+
+	t.Run("mongo", func(t *testing.T) {
+		client, db := newFakeMongoClient()
+		db.collection(mongoParticipantsCollection).racyUpserts = true
+		eventID := testObjectID(60)
+		db.collection(mongoEventsCollection).insert(mongoEventDoc{
+			ID: eventID, Name: "Event", Date: future, DateEnd: future.Add(time.Hour),
+			Deadline: future.Add(-time.Hour), ParticipantLimit: limit, CreatedBy: mongoHostID, Status: EventStatusActive,
+		})
+		joined, refused := joinConcurrently(t, client, eventID.Hex(), hexUsers)
+		checkJoins(t, len(hexUsers), limit, joined, refused)
+
+		n, err := client.participants.CountDocuments(context.Background(), bson.D{{Key: "events_participants_event", Value: eventID}})
+		if err != nil || n != limit {
+			t.Errorf("%d participant records, %v", n, err)
+		}
+		event, err := client.GetEvent(context.Background(), eventID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Participants.RemainNumber != 0 {
+			t.Errorf("%d seats left", event.Participants.RemainNumber)
+		}
+	})
+
+	t.Run("mongo same user", func(t *testing.T) {
+		client, db := newFakeMongoClient()
+		db.collection(mongoParticipantsCollection).racyUpserts = true
+		eventID := testObjectID(61)
+		db.collection(mongoEventsCollection).insert(mongoEventDoc{
+			ID: eventID, Name: "Event", Date: future, DateEnd: future.Add(time.Hour),
+			Deadline: future.Add(-time.Hour), ParticipantLimit: limit, CreatedBy: mongoHostID, Status: EventStatusActive,
+		})
+		userID := bson.NewObjectID().Hex()
+		attempts := make([]string, 200)
+		for i := range attempts {
+			attempts[i] = userID
+		}
+		joined, refused := joinConcurrently(t, client, eventID.Hex(), attempts)
+		checkJoins(t, len(attempts), 1, joined, refused)
+		if refused[ErrAlreadyJoined] != len(attempts)-1 {
+			t.Errorf("refusals %v", refused)
+		}
+
+		event, err := client.GetEvent(context.Background(), eventID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Participants.RemainNumber != limit-1 {
+			t.Errorf("%d seats left, want %d", event.Participants.RemainNumber, limit-1)
+		}
+	})
+}
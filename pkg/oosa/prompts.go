@@ -0,0 +1,286 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// chineseSpellings returns the ways of writing c that events use, the
+// Chinese ones.
+func chineseSpellings(c Category) []string {
+	var words []string
+	for _, s := range categorySpellings(c) {
+		if strings.IndexFunc(s, isCJK) >= 0 {
+			words = append(words, s)
+		}
+	}
+	return words
+}
+
+// promptArg returns a trimmed prompt argument.
+func promptArg(request mcp.GetPromptRequest, name string) string {
+	return strings.TrimSpace(request.Params.Arguments[name])
+}
+
+// splitList splits a comma separated argument, accepting the full-width
+// comma and 、 as well.
+func splitList(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、'
+	})
+	items := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			items = append(items, f)
+		}
+	}
+	return items
+}
+
+func PlanOuting() (prompt mcp.Prompt, handler server.PromptHandlerFunc) {
+	return mcp.NewPrompt("plan_outing",
+			mcp.WithPromptDescription("Plan an outing from OOSA events: finds events matching a date range, area, budget and interests, and returns a shortlist with reasons"),
+			mcp.WithArgument("date_period_begin",
+				mcp.ArgumentDescription("First day the user is free, e.g. 2025-05-01 (Asia/Taipei) or an RFC3339 timestamp"),
+			),
+			mcp.WithArgument("date_period_end",
+				mcp.ArgumentDescription("Last day the user is free, e.g. 2025-05-04"),
+			),
+			mcp.WithArgument("area",
+				mcp.ArgumentDescription("Where the user wants to go, as a place name (e.g. 台北 信義區) or a coordinate (25.0330,121.5654)"),
+			),
+			mcp.WithArgument("budget",
+				mcp.ArgumentDescription("Maximum fee per person in TWD; 0 for free events only"),
+			),
+			mcp.WithArgument("interests",
+				mcp.ArgumentDescription("Comma separated interests, e.g. hiking, food, hot springs"),
+			),
+			mcp.WithArgument("party_size",
+				mcp.ArgumentDescription("Number of people who want to join (default 1)"),
+			),
+		),
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			dateBegin := promptArg(request, "date_period_begin")
+			dateEnd := promptArg(request, "date_period_end")
+			if _, err := parsePeriodFilter("date", "", dateBegin, dateEnd, taipei); err != nil {
+				return nil, err
+			}
+
+			budget := promptArg(request, "budget")
+			if budget != "" {
+				if v, err := strconv.ParseFloat(budget, 64); err != nil || v < 0 {
+					return nil, fmt.Errorf("invalid budget %q: expected an amount in TWD", budget)
+				}
+			}
+
+			partySize := 1
+			if s := promptArg(request, "party_size"); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("invalid party_size %q: expected a positive number", s)
+				}
+				partySize = n
+			}
+
+			area := promptArg(request, "area")
+			interests := splitList(promptArg(request, "interests"))
+
+			// What the user asked for, as it will be shown to the model.
+			var criteria strings.Builder
+			criteria.WriteString("Help me plan an outing.\n")
+			switch {
+			case dateBegin != "" && dateEnd != "":
+				fmt.Fprintf(&criteria, "- When: between %s and %s\n", dateBegin, dateEnd)
+			case dateBegin != "":
+				fmt.Fprintf(&criteria, "- When: from %s on\n", dateBegin)
+			case dateEnd != "":
+				fmt.Fprintf(&criteria, "- When: any time until %s\n", dateEnd)
+			default:
+				criteria.WriteString("- When: any upcoming date\n")
+			}
+			if area != "" {
+				fmt.Fprintf(&criteria, "- Where: %s\n", area)
+			}
+			if budget != "" {
+				fmt.Fprintf(&criteria, "- Budget: at most NT$%s per person\n", budget)
+			}
+			if len(interests) > 0 {
+				fmt.Fprintf(&criteria, "- Interests: %s\n", strings.Join(interests, ", "))
+			}
+			fmt.Fprintf(&criteria, "- Party size: %d\n", partySize)
+
+			// How to find the events.
+			var steps strings.Builder
+			steps.WriteString("You are planning an outing with OOSA, a platform for group outings in Taiwan. Use only events returned by the OOSA tools; never invent events, times or prices.\n\n")
+			steps.WriteString("Steps:\n")
+			fmt.Fprintf(&steps, "1. Call get_events with event_past=\"false\"")
+			if dateBegin != "" {
+				fmt.Fprintf(&steps, ", event_period_begin=%q", dateBegin)
+			}
+			if dateEnd != "" {
+				fmt.Fprintf(&steps, ", event_period_end=%q", dateEnd)
+			}
+			steps.WriteString(". Follow next_cursor until has_more is false.\n")
+			if area != "" {
+				fmt.Fprintf(&steps, "2. Call find_events_nearby around %s (use its coordinates; start with radius_km=10 and widen to 30 if fewer than three events match), with match_on=\"either\". Prefer events that are close.\n", area)
+			} else {
+				steps.WriteString("2. No area was given, so do not restrict by distance.\n")
+			}
+			if len(interests) > 0 {
+				steps.WriteString("3. Find events for each interest. Events are written in Traditional Chinese, so search with Chinese keywords:\n")
+				for _, interest := range interests {
+					if c, ok := LookupCategory(interest); ok && c.ID != CategoryOther {
+						fmt.Fprintf(&steps, "   - %s: get_events with type=%q, and search_events with %s\n", interest, c.ID, strings.Join(chineseSpellings(c), ", "))
+					} else {
+						fmt.Fprintf(&steps, "   - %s: the interest itself and its usual Chinese wording\n", interest)
+					}
+				}
+			} else {
+				steps.WriteString("3. No interests were given; consider every kind of event.\n")
+			}
+			steps.WriteString("4. Keep only events that:\n")
+			steps.WriteString("   - have events_status \"active\"\n")
+			steps.WriteString("   - have an events_deadline still in the future\n")
+			fmt.Fprintf(&steps, "   - have at least %d seat(s) left (events_participants.remain_number)\n", partySize)
+			if budget != "" {
+				fmt.Fprintf(&steps, "   - cost at most NT$%s per person (events_payment_fee)\n", budget)
+			}
+			steps.WriteString("5. Call get_event for the best candidates to read their description before recommending them.\n\n")
+			steps.WriteString("Answer with a shortlist of at most five events, best first. For each give: name, date and time in Asia/Taipei, place and meeting point, fee, seats left, registration deadline, and one or two sentences on why it fits the request. ")
+			steps.WriteString("Then mention any close misses (e.g. full, slightly over budget) in one line each. If nothing matches, say so and suggest how to relax the criteria. Reply in the language the user writes in.")
+
+			return mcp.NewGetPromptResult(
+				"Plan an outing from OOSA events",
+				[]mcp.PromptMessage{
+					mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(steps.String())),
+					mcp.NewPromptMessage(mcp.RoleAssistant, mcp.NewTextContent("Understood. Tell me what you are looking for and I will search the OOSA events and come back with a shortlist.")),
+					mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(criteria.String())),
+				},
+			), nil
+		}
+}
+
+// AnnouncementChannel is where a host posts an event announcement.
+type AnnouncementChannel string
+
+const (
+	AnnouncementLINE     AnnouncementChannel = "line"
+	AnnouncementFacebook AnnouncementChannel = "facebook"
+	AnnouncementEmail    AnnouncementChannel = "email"
+)
+
+// announcementStyles describes the tone and length that suit each channel.
+var announcementStyles = map[AnnouncementChannel]string{
+	AnnouncementLINE: "a LINE group message: friendly and short, at most about 300 characters per language, " +
+		"plain text only (LINE shows markdown literally), a few emoji, each key fact on its own line",
+	AnnouncementFacebook: "a Facebook post: warm and inviting, about 150 to 250 words per language, " +
+		"an opening line that hooks the reader, short paragraphs, a few emoji and three to five hashtags at the end",
+	AnnouncementEmail: "an email: polite and complete, a bilingual subject line first, then a greeting, " +
+		"the key facts as a list, how to sign up, and a sign-off with the host's name",
+}
+
+func DraftEventAnnouncement(store EventStore) (prompt mcp.Prompt, handler server.PromptHandlerFunc) {
+	return mcp.NewPrompt("draft_event_announcement",
+			mcp.WithPromptDescription("Draft a bilingual (zh-TW/en) announcement of an OOSA event for a LINE group, a Facebook post or an email"),
+			mcp.WithArgument("events_id",
+				mcp.ArgumentDescription("The ID of the event to announce"),
+				mcp.RequiredArgument(),
+			),
+			mcp.WithArgument("channel",
+				mcp.ArgumentDescription("Where the announcement goes, which sets its tone and length: line, facebook or email (default line)"),
+			),
+			mcp.WithArgument("tone",
+				mcp.ArgumentDescription("Optional tone to use instead of the channel's usual one, e.g. playful, formal, urgent"),
+			),
+		),
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			eventID := promptArg(request, "events_id")
+			if eventID == "" {
+				return nil, fmt.Errorf("missing required argument: events_id")
+			}
+
+			channel := AnnouncementChannel(strings.ToLower(promptArg(request, "channel")))
+			if channel == "" {
+				channel = AnnouncementLINE
+			}
+			style, ok := announcementStyles[channel]
+			if !ok {
+				return nil, fmt.Errorf("invalid channel %q: expected line, facebook or email", channel)
+			}
+			tone := promptArg(request, "tone")
+
+			event, err := store.GetEvent(ctx, eventID)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return nil, fmt.Errorf("event not found: %s", eventID)
+				}
+				return nil, fmt.Errorf("failed to get event: %w", err)
+			}
+			if event.Status == EventStatusCancelled {
+				return nil, fmt.Errorf("event %s is cancelled", eventID)
+			}
+
+			contents, err := jsonResource(EventResourceURI(eventID), event)
+			if err != nil {
+				return nil, err
+			}
+
+			// The facts the announcement must get right, worked out here so
+			// the model does not have to convert times or count seats.
+			var facts strings.Builder
+			fmt.Fprintf(&facts, "- Event: %s\n", event.Name)
+			fmt.Fprintf(&facts, "- When: %s to %s\n", event.Date.Display(taipei), event.DateEnd.Display(taipei))
+			fmt.Fprintf(&facts, "- Where: %s\n", event.Place)
+			if event.MeetingPointName != "" {
+				fmt.Fprintf(&facts, "- Meeting point: %s\n", event.MeetingPointName)
+			}
+			if event.PaymentRequired != 0 {
+				fmt.Fprintf(&facts, "- Fee: NT$%s per person\n", strconv.FormatFloat(event.PaymentFee, 'f', -1, 64))
+			} else {
+				facts.WriteString("- Fee: free\n")
+			}
+			fmt.Fprintf(&facts, "- Sign-up deadline: %s\n", event.Deadline.Display(taipei))
+			remain := int64(event.ParticipantLimit)
+			if event.Participants != nil {
+				remain = event.Participants.RemainNumber
+			}
+			fmt.Fprintf(&facts, "- Seats left: %d of %d\n", remain, int64(event.ParticipantLimit))
+			if event.Host != nil {
+				fmt.Fprintf(&facts, "- Host: %s\n", event.Host.Name)
+			}
+
+			var brief strings.Builder
+			brief.WriteString("I host the OOSA event attached above. Write its announcement as ")
+			brief.WriteString(style)
+			brief.WriteString(".\n")
+			if tone != "" {
+				fmt.Fprintf(&brief, "Use a %s tone.\n", tone)
+			}
+			brief.WriteString("\nWrite it in Traditional Chinese (zh-TW, as used in Taiwan) first, then in English. ")
+			brief.WriteString("The two versions carry the same facts but should each read naturally, not as a word-for-word translation.\n\n")
+			brief.WriteString("Both versions must state these facts exactly (times are Asia/Taipei):\n")
+			brief.WriteString(facts.String())
+			brief.WriteString("\nUse the event description for what makes the event worth joining, but do not invent details that are not in the event data. ")
+			switch {
+			case remain <= 0:
+				brief.WriteString("The event is full, so say so and invite readers to watch for seats opening up rather than urging them to sign up. ")
+			case remain <= 3:
+				brief.WriteString("Only a few seats are left, so mention that readers should sign up soon. ")
+			}
+			brief.WriteString("End with a clear call to sign up on OOSA before the deadline. Reply with the announcement only.")
+
+			return mcp.NewGetPromptResult(
+				fmt.Sprintf("Announcement of %s", event.Name),
+				[]mcp.PromptMessage{
+					mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(contents[0])),
+					mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(brief.String())),
+				},
+			), nil
+		}
+}
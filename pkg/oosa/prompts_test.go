@@ -0,0 +1,173 @@
+package oosa
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// promptMessage is a message of a prompts/get result, text or an embedded
+// resource.
+type promptMessage struct {
+	Role    string `json:"role"`
+	Content struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Resource struct {
+			URI      string `json:"uri"`
+			MIMEType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"resource"`
+	} `json:"content"`
+}
+
+// getPrompt gets a prompt, returning its messages or, if it was refused, the
+// error message.
+func getPrompt(t *testing.T, client *testClient, name string, args map[string]string) ([]promptMessage, string) {
+	t.Helper()
+	var response struct {
+		Result *struct {
+			Messages []promptMessage `json:"messages"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	raw := client.request("prompts/get", map[string]any{"name": name, "arguments": args})
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		return nil, response.Error.Message
+	}
+	if response.Result == nil {
+		t.Fatalf("%s: %s", name, raw)
+	}
+	return response.Result.Messages, ""
+}
+
+func TestPlanOutingArgs(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	for name, values := range map[string][]string{
+		"budget":            {"-100", "cheap"},
+		"party_size":        {"0", "two", "1.5"},
+		"date_period_begin": {"next week"},
+	} {
+		for _, value := range values {
+			if _, refused := getPrompt(t, client, "plan_outing", map[string]string{name: value}); !strings.Contains(refused, name) {
+				t.Errorf("%s=%s: %q, want it refused", name, value, refused)
+			}
+		}
+	}
+
+	messages, refused := getPrompt(t, client, "plan_outing", map[string]string{"budget": "0", "party_size": " 3 ", "area": "北投"})
+	if refused != "" {
+		t.Fatal(refused)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("%d messages", len(messages))
+	}
+	criteria := messages[2].Content.Text
+	for _, want := range []string{"- Budget: at most NT$0 per person\n", "- Party size: 3\n", "- Where: 北投\n"} {
+		if !strings.Contains(criteria, want) {
+			t.Errorf("criteria lack %q:\n%s", want, criteria)
+		}
+	}
+	if steps := messages[0].Content.Text; !strings.Contains(steps, "at least 3 seat(s) left") || !strings.Contains(steps, "find_events_nearby around 北投") {
+		t.Errorf("steps:\n%s", steps)
+	}
+}
+
+func TestPlanOutingKeywords(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	messages, refused := getPrompt(t, client, "plan_outing", map[string]string{"interests": "hot springs，夜市、 knitting"})
+	if refused != "" {
+		t.Fatal(refused)
+	}
+	steps := messages[0].Content.Text
+	for _, want := range []string{
+		// Interests in English are searched for in Chinese.
+		`   - hot springs: get_events with type="hot_spring", and search_events with 溫泉, 泡湯, 湯屋` + "\n",
+		`   - 夜市: get_events with type="food", and search_events with 美食, 小吃, 夜市, 吃貨` + "\n",
+		"   - knitting: the interest itself and its usual Chinese wording\n",
+	} {
+		if !strings.Contains(steps, want) {
+			t.Errorf("steps lack %q:\n%s", want, steps)
+		}
+	}
+	if strings.Contains(steps, "onsen") {
+		t.Errorf("steps search with an English synonym:\n%s", steps)
+	}
+	if criteria := messages[2].Content.Text; !strings.Contains(criteria, "- Interests: hot springs, 夜市, knitting\n") {
+		t.Errorf("criteria:\n%s", criteria)
+	}
+}
+
+func TestDraftEventAnnouncement(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	messages, refused := getPrompt(t, client, "draft_event_announcement", map[string]string{"events_id": "event1", "channel": "Facebook"})
+	if refused != "" {
+		t.Fatal(refused)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("%d messages", len(messages))
+	}
+
+	// The event itself comes first, as the event resource.
+	embedded := messages[0].Content
+	if embedded.Type != "resource" || embedded.Resource.URI != EventResourceURI("event1") || embedded.Resource.MIMEType != "application/json" {
+		t.Errorf("first message %+v, want the event resource", embedded)
+	}
+	var event EventDetail
+	if err := json.Unmarshal([]byte(embedded.Resource.Text), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != "event1" || event.Name != "象山步道健行賞夜景" || event.Description == "" {
+		t.Errorf("embedded event %+v", event.Event)
+	}
+	if read := client.request("resources/read", map[string]any{"uri": EventResourceURI("event1")}); !strings.Contains(read, jsonString(t, embedded.Resource.Text)) {
+		t.Errorf("embedded event differs from the resource:\n%s\n%s", embedded.Resource.Text, read)
+	}
+
+	brief := messages[1].Content.Text
+	for _, want := range []string{
+		"as a Facebook post",
+		"- When: 2025-04-12 (Sat) 00:00 to 2025-04-12 (Sat) 04:00\n",
+		"- Fee: free\n",
+		"- Seats left: 12 of 15\n",
+		"- Host: 王小明\n",
+	} {
+		if !strings.Contains(brief, want) {
+			t.Errorf("brief lacks %q:\n%s", want, brief)
+		}
+	}
+}
+
+// jsonString quotes s as it appears inside JSON.
+func jsonString(t *testing.T, s string) string {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDraftEventAnnouncementRefuses(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	client.as("user1").callTool("cancel_event", map[string]any{"events_id": "event4", "reason": "豪雨特報"}, nil)
+
+	for _, tt := range []struct {
+		args map[string]string
+		want string
+	}{
+		{map[string]string{}, "missing required argument: events_id"},
+		{map[string]string{"events_id": "nope"}, "event not found: nope"},
+		{map[string]string{"events_id": "event4"}, "event event4 is cancelled"},
+		{map[string]string{"events_id": "event1", "channel": "fax"}, `invalid channel "fax"`},
+	} {
+		if _, refused := getPrompt(t, client, "draft_event_announcement", tt.args); !strings.Contains(refused, tt.want) {
+			t.Errorf("%v: %q, want %q", tt.args, refused, tt.want)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package oosa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Format selects how a tool renders its result.
+type Format string
+
+const (
+	// FormatJSON is indented JSON with the field names of the OOSA API.
+	FormatJSON Format = "json"
+	// FormatCompact is single-line JSON with short keys and without empty
+	// fields, to save tokens.
+	FormatCompact Format = "compact"
+	// FormatMarkdown is tables and lists meant to be read by a model.
+	FormatMarkdown Format = "markdown"
+	// FormatGeoJSON is a GeoJSON FeatureCollection, offered by get_events.
+	FormatGeoJSON Format = "geojson"
+)
+
+// renderFormats are the formats every tool renders.
+var renderFormats = []Format{FormatJSON, FormatCompact, FormatMarkdown}
+
+// OutputParams say how a tool renders its result.
+type OutputParams struct {
+	Format Format
+	// Location is the time zone timestamps are shown in.
+	Location *time.Location
+}
+
+// WithOutput returns a ToolOption that adds the "format" and "timezone"
+// parameters to the tool. format offers the common formats plus any extra
+// ones the tool handles itself.
+func WithOutput(extra ...Format) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		var names []string
+		for _, f := range slices.Concat(renderFormats, extra) {
+			names = append(names, string(f))
+		}
+		mcp.WithString("format",
+			mcp.Description(fmt.Sprintf("How to render the result: %s (default json). compact is JSON with short keys, markdown is tables and lists", strings.Join(names, ", "))),
+			mcp.Enum(names...),
+		)(tool)
+
+		mcp.WithString("timezone",
+			mcp.Description("IANA time zone to show times in, e.g. Asia/Taipei or UTC (default Asia/Taipei)"),
+		)(tool)
+	}
+}
+
+// OptionalOutputParams returns the "format" and "timezone" parameters from
+// the request, FormatJSON and Asia/Taipei if not present. extra lists the
+// formats the tool adds to the common ones.
+func OptionalOutputParams(r mcp.CallToolRequest, extra ...Format) (OutputParams, error) {
+	v, err := OptionalParam[string](r, "format")
+	if err != nil {
+		return OutputParams{}, err
+	}
+	format := FormatJSON
+	if v != "" {
+		allowed := slices.Concat(renderFormats, extra)
+		if !slices.Contains(allowed, Format(v)) {
+			names := make([]string, len(allowed))
+			for i, f := range allowed {
+				names[i] = string(f)
+			}
+			return OutputParams{}, fmt.Errorf("invalid format %q: expected one of %s", v, strings.Join(names, ", "))
+		}
+		format = Format(v)
+	}
+
+	timezone, err := OptionalParam[string](r, "timezone")
+	if err != nil {
+		return OutputParams{}, err
+	}
+	loc, err := parseTimezone(timezone)
+	if err != nil {
+		return OutputParams{}, err
+	}
+
+	return OutputParams{Format: format, Location: loc}, nil
+}
+
+// renderResult renders v, a JSON-encodable tool result, as out asks. A
+// result that cannot be rendered is reported as an internal error.
+func renderResult(v any, out OutputParams) (*mcp.CallToolResult, error) {
+	text, err := render(v, out)
+	if err != nil {
+		return internalError(err).Result(), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+func render(v any, out OutputParams) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode result: %w", err)
+	}
+	loc := out.Location
+	if loc == nil {
+		loc = taipei
+	}
+
+	var r []byte
+	switch out.Format {
+	case FormatJSON:
+		r, err = json.MarshalIndent(localizeTimes(value, loc, time.RFC3339), "", "  ")
+	case FormatGeoJSON:
+		r, err = json.Marshal(localizeTimes(value, loc, time.RFC3339))
+	case FormatCompact:
+		r, err = json.Marshal(compactValue(localizeTimes(value, loc, time.RFC3339)))
+	case FormatMarkdown:
+		return markdown(compactValue(collapseUsers(localizeTimes(value, loc, displayLayout)))), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", out.Format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(r), nil
+}
+
+// timeFields are the fields holding a Time, which are shown in the time zone
+// the caller asked for.
+var timeFields = map[string]bool{
+	"events_date":         true,
+	"events_date_end":     true,
+	"events_deadline":     true,
+	"events_created_at":   true,
+	"events_cancelled_at": true,
+	"ideas_date":          true,
+	"ideas_date_end":      true,
+	"ideas_created_at":    true,
+}
+
+// durationFields name the start and end of the things that last a while,
+// and the field their duration is added as, right after the end.
+var durationFields = []struct {
+	start, end, duration string
+}{
+	{"events_date", "events_date_end", "events_duration"},
+	{"ideas_date", "ideas_date_end", "ideas_duration"},
+}
+
+// localizeTimes rewrites the timeFields of every object in v in loc, using
+// layout, and adds the duration of events and ideas.
+func localizeTimes(v any, loc *time.Location, layout string) any {
+	switch v := v.(type) {
+	case *orderedMap:
+		m := &orderedMap{values: make(map[string]any)}
+		times := make(map[string]time.Time)
+		for _, k := range v.keys {
+			value := v.values[k]
+			if s, ok := value.(string); ok && timeFields[k] {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					times[k] = t
+					value = t.In(loc).Format(layout)
+				}
+			} else {
+				value = localizeTimes(value, loc, layout)
+			}
+			m.set(k, value)
+			for _, f := range durationFields {
+				start, hasStart := times[f.start]
+				end, hasEnd := times[f.end]
+				if k == f.end && hasStart && hasEnd {
+					m.set(f.duration, formatDuration(end.Sub(start)))
+				}
+			}
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = localizeTimes(item, loc, layout)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// orderedMap is a JSON object that keeps the order of its keys, so rendered
+// results list fields in the order of the Go structs.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(k string, v any) {
+	if _, ok := m.values[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.values[k] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// decodeOrdered decodes JSON into *orderedMap, []any, json.Number, string,
+// bool and nil values.
+func decodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := &orderedMap{values: make(map[string]any)}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", keyTok)
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.set(key, v)
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	default:
+		return tok, nil
+	}
+}
+
+// shortKeys renames the fields whose name stays long once its prefix is
+// dropped, or would clash with another field of the same object.
+var shortKeys = map[string]string{
+	"events_date_end":           "end",
+	"events_meeting_point_name": "meet",
+	"events_meeting_point_lat":  "meet_lat",
+	"events_meeting_point_lng":  "meet_lng",
+	"events_participant_limit":  "limit",
+	"events_payment_required":   "paid",
+	"events_payment_fee":        "fee",
+	"events_created_by_user":    "by",
+	"events_created_at":         "created",
+	"events_participants":       "seats",
+	"events_participant_list":   "participants",
+	"events_cancel_reason":      "cancel_reason",
+	"events_cancelled_at":       "cancelled",
+	"ideas_date_end":            "end",
+	"ideas_created_by_user":     "by",
+	"ideas_created_at":          "created",
+	"latest_tree_user":          "latest",
+	"remain_number":             "remain",
+	"user_events_hosted":        "hosted",
+	"user_upcoming_events":      "upcoming",
+	"total_count":               "total",
+	"next_cursor":               "cursor",
+	"has_more":                  "more",
+	"distance_km":               "km",
+	"matched_on":                "on",
+}
+
+// shortKey returns the compact name of a field: events_, ideas_ and user_
+// prefixes are dropped, and the longest names are replaced.
+func shortKey(k string) string {
+	if s, ok := shortKeys[k]; ok {
+		return s
+	}
+	for _, prefix := range []string{"events_", "ideas_", "user_"} {
+		if s, ok := strings.CutPrefix(k, prefix); ok && s != "" {
+			return s
+		}
+	}
+	return k
+}
+
+// compactValue shortens the keys of every object in v and drops empty
+// fields: null, "", and empty lists and objects. Zeros and false are kept,
+// as they carry meaning (a free event, no seats left).
+func compactValue(v any) any {
+	switch v := v.(type) {
+	case *orderedMap:
+		m := &orderedMap{values: make(map[string]any)}
+		for _, k := range v.keys {
+			value := compactValue(v.values[k])
+			if isEmpty(value) {
+				continue
+			}
+			m.set(shortKey(k), value)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = compactValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case *orderedMap:
+		return len(v.keys) == 0
+	default:
+		return false
+	}
+}
+
+// collapseUsers replaces every user object by the user's name, which is all
+// a reader of the markdown needs. User objects are the ones whose fields all
+// start with user_.
+func collapseUsers(v any) any {
+	switch v := v.(type) {
+	case *orderedMap:
+		if name, ok := userName(v); ok {
+			return name
+		}
+		m := &orderedMap{values: make(map[string]any)}
+		for _, k := range v.keys {
+			m.set(k, collapseUsers(v.values[k]))
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = collapseUsers(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func userName(m *orderedMap) (string, bool) {
+	if len(m.keys) == 0 {
+		return "", false
+	}
+	for _, k := range m.keys {
+		if !strings.HasPrefix(k, "user_") {
+			return "", false
+		}
+	}
+	name, ok := m.values["user_name"].(string)
+	return name, ok
+}
+
+// markdown renders a compacted value: objects become bullet lists, and lists
+// of objects become tables.
+func markdown(v any) string {
+	var b strings.Builder
+	switch v := v.(type) {
+	case *orderedMap:
+		markdownObject(&b, v, "")
+	case []any:
+		markdownList(&b, v)
+	default:
+		b.WriteString(inline(v))
+		b.WriteString("\n")
+	}
+	return strings.Trim(b.String(), "\n")
+}
+
+func markdownObject(b *strings.Builder, m *orderedMap, indent string) {
+	for _, k := range m.keys {
+		switch v := m.values[k].(type) {
+		case *orderedMap:
+			fmt.Fprintf(b, "%s- **%s**:\n", indent, k)
+			markdownObject(b, v, indent+"  ")
+		case []any:
+			if isTable(v) && indent == "" {
+				fmt.Fprintf(b, "\n**%s** (%d):\n\n", k, len(v))
+				markdownList(b, v)
+				b.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(b, "%s- **%s**: %s\n", indent, k, inline(v))
+		default:
+			fmt.Fprintf(b, "%s- **%s**: %s\n", indent, k, inline(v))
+		}
+	}
+}
+
+// isTable reports whether a list holds objects, which render as table rows.
+func isTable(list []any) bool {
+	for _, item := range list {
+		if _, ok := item.(*orderedMap); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// markdownList renders a list as a table when it holds objects, with a
+// column for every field found in any row.
+func markdownList(b *strings.Builder, list []any) {
+	if !isTable(list) {
+		for _, item := range list {
+			fmt.Fprintf(b, "- %s\n", inline(item))
+		}
+		return
+	}
+
+	columns := &orderedMap{values: make(map[string]any)}
+	rows := make([]*orderedMap, len(list))
+	for i, item := range list {
+		row := &orderedMap{values: make(map[string]any)}
+		flatten(row, "", item.(*orderedMap))
+		for _, k := range row.keys {
+			columns.set(k, nil)
+		}
+		rows[i] = row
+	}
+
+	b.WriteString("| " + strings.Join(columns.keys, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns.keys)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(columns.keys))
+		for i, k := range columns.keys {
+			if v, ok := row.values[k]; ok {
+				cells[i] = inline(v)
+			}
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+}
+
+// flatten copies the fields of nested objects into row, so that each table
+// row is one line. Nested fields keep their own name unless it is taken, in
+// which case they are prefixed with the name of their parent.
+func flatten(row *orderedMap, parent string, m *orderedMap) {
+	for _, k := range m.keys {
+		name := k
+		if _, taken := row.values[name]; taken && parent != "" {
+			name = parent + "." + k
+		}
+		if nested, ok := m.values[k].(*orderedMap); ok {
+			flatten(row, name, nested)
+			continue
+		}
+		row.set(name, m.values[k])
+	}
+}
+
+// inline renders a value on one line, safe to put in a table cell.
+func inline(v any) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = v
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = inline(item)
+		}
+		return strings.Join(items, ", ")
+	case *orderedMap:
+		fields := make([]string, len(v.keys))
+		for i, k := range v.keys {
+			fields[i] = k + ": " + inline(v.values[k])
+		}
+		return strings.Join(fields, "; ")
+	default:
+		s = fmt.Sprint(v)
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", `\|`)
+}
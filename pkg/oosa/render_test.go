@@ -0,0 +1,184 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// mockEvent returns an event of the mock data, as get_event returns it.
+func mockEvent(t *testing.T, eventID string) EventDetail {
+	t.Helper()
+	event, err := NewMockClient().GetEvent(context.Background(), eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *event
+}
+
+// renderAs renders v in format, in Asia/Taipei.
+func renderAs(t *testing.T, v any, format Format) string {
+	t.Helper()
+	text, err := render(v, OutputParams{Format: format})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestRenderEvent(t *testing.T) {
+	event := mockEvent(t, "event1")
+
+	text := renderAs(t, event, FormatJSON)
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		t.Fatal(err)
+	}
+	// 16:00 UTC is midnight in Taipei.
+	if fields["events_date"] != "2025-04-12T00:00:00+08:00" || fields["events_duration"] != "4h" || fields["events_name"] != event.Name {
+		t.Errorf("json: %v %v %v", fields["events_date"], fields["events_duration"], fields["events_name"])
+	}
+	if !strings.Contains(text, "\n  \"events_id\": \"event1\"") {
+		t.Errorf("json not indented:\n%s", text)
+	}
+	if strings.Index(text, `"events_date_end"`) > strings.Index(text, `"events_duration"`) {
+		t.Errorf("duration not right after the end:\n%s", text)
+	}
+
+	text = renderAs(t, event, FormatCompact)
+	if strings.Contains(text, "\n") || strings.Contains(text, "events_") {
+		t.Errorf("compact: %s", text)
+	}
+	var compact map[string]any
+	if err := json.Unmarshal([]byte(text), &compact); err != nil {
+		t.Fatal(err)
+	}
+	if compact["name"] != event.Name || compact["end"] != "2025-04-12T04:00:00+08:00" || compact["meet"] != event.MeetingPointName || compact["limit"] != 15.0 {
+		t.Errorf("compact: %s", text)
+	}
+	// Zeros stay, as a free event is worth saying; empty fields go.
+	if compact["paid"] != 0.0 || compact["fee"] != 0.0 {
+		t.Errorf("compact dropped the fee: %s", text)
+	}
+	if _, ok := compact["cancel_reason"]; ok {
+		t.Errorf("compact kept an empty field: %s", text)
+	}
+	if seats, _ := compact["seats"].(map[string]any); seats["remain"] != 12.0 {
+		t.Errorf("compact seats: %v", compact["seats"])
+	}
+
+	text = renderAs(t, event, FormatMarkdown)
+	for _, want := range []string{
+		"- **name**: 象山步道健行賞夜景\n",
+		"- **date**: 2025-04-12 (Sat) 00:00\n",
+		"- **duration**: 4h\n",
+		// Users are shown by name.
+		"- **by**: 王小明\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRenderEventsPage(t *testing.T) {
+	page := EventsPage{
+		Events:     []Event{mockEvent(t, "event1").Event, mockEvent(t, "event2").Event},
+		TotalCount: 5,
+		NextCursor: "next",
+		HasMore:    true,
+	}
+
+	var decoded EventsPage
+	if err := json.Unmarshal([]byte(renderAs(t, page, FormatJSON)), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Events) != 2 || decoded.Events[1].ID != "event2" || decoded.TotalCount != 5 || decoded.NextCursor != "next" || !decoded.HasMore {
+		t.Errorf("json: %+v", decoded)
+	}
+
+	var compact struct {
+		Events []map[string]any `json:"events"`
+		Total  int              `json:"total"`
+		Cursor string           `json:"cursor"`
+		More   bool             `json:"more"`
+	}
+	if err := json.Unmarshal([]byte(renderAs(t, page, FormatCompact)), &compact); err != nil {
+		t.Fatal(err)
+	}
+	if len(compact.Events) != 2 || compact.Events[1]["id"] != "event2" || compact.Total != 5 || compact.Cursor != "next" || !compact.More {
+		t.Errorf("compact: %+v", compact)
+	}
+
+	text := renderAs(t, page, FormatMarkdown)
+	lines := strings.Split(text, "\n")
+	if lines[0] != "**events** (2):" || !strings.HasPrefix(lines[2], "| id | name | date | end | duration |") || !strings.HasPrefix(lines[3], "| --- |") {
+		t.Errorf("markdown table:\n%s", text)
+	}
+	if !strings.HasPrefix(lines[4], "| event1 | 象山步道健行賞夜景 |") || !strings.HasPrefix(lines[5], "| event2 |") {
+		t.Errorf("markdown rows:\n%s", text)
+	}
+	if !strings.Contains(text, "- **total**: 5\n- **cursor**: next\n- **more**: true") {
+		t.Errorf("markdown page fields:\n%s", text)
+	}
+}
+
+func TestRenderMarkdownEscapes(t *testing.T) {
+	event := mockEvent(t, "event1").Event
+	event.Name = "夜景 | 星空\n第二行"
+	page := EventsPage{Events: []Event{event}, TotalCount: 1}
+
+	text := renderAs(t, page, FormatMarkdown)
+	lines := strings.Split(text, "\n")
+	row := lines[4]
+	if !strings.Contains(row, `| 夜景 \| 星空 第二行 |`) {
+		t.Errorf("row %q", row)
+	}
+	if columns := strings.Count(lines[2], " | "); strings.Count(strings.ReplaceAll(row, `\|`, ""), " | ") != columns {
+		t.Errorf("row and header differ in columns:\n%s\n%s", lines[2], row)
+	}
+	if lines[5] != "" {
+		t.Errorf("row split over lines:\n%s", text)
+	}
+}
+
+func TestCompactKeys(t *testing.T) {
+	for key, want := range map[string]string{
+		"events_name":               "name",
+		"events_date_end":           "end",
+		"events_meeting_point_name": "meet",
+		"events_participants":       "seats",
+		"latest_tree_user":          "latest",
+		"ideas_title":               "title",
+		"user_name":                 "name",
+		"total_count":               "total",
+		"next_cursor":               "cursor",
+		"distance_km":               "km",
+		"events_":                   "events_",
+		"score":                     "score",
+	} {
+		if got := shortKey(key); got != want {
+			t.Errorf("shortKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	// No two fields of an object may end up with the same key.
+	for _, v := range []any{EventDetail{}, Idea{}, UserProfile{}, EventsPage{}, NearbyEvent{}} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[string]string)
+		for k := range fields {
+			if prev, ok := seen[shortKey(k)]; ok {
+				t.Errorf("%T: %s and %s are both %s", v, prev, k, shortKey(k))
+			}
+			seen[shortKey(k)] = k
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// EventsResourceURI lists the upcoming events.
+	EventsResourceURI = "oosa://events"
+	// EventResourceURITemplate serves one event in full.
+	EventResourceURITemplate = "oosa://events/{events_id}"
+	// UserResourceURITemplate serves the profile of a user.
+	UserResourceURITemplate = "oosa://users/{user_id}"
+)
+
+// EventResourceURI returns the URI of the resource serving one event.
+func EventResourceURI(eventID string) string {
+	return "oosa://events/" + eventID
+}
+
+// UserResourceURI returns the URI of the resource serving a user profile.
+func UserResourceURI(userID string) string {
+	return "oosa://users/" + userID
+}
+
+// resourceArg returns a variable matched from a resource URI template.
+func resourceArg(request mcp.ReadResourceRequest, name string) (string, error) {
+	v, ok := request.Params.Arguments[name]
+	if !ok {
+		return "", fmt.Errorf("missing %s in %s", name, request.Params.URI)
+	}
+	// Template variables arrive as a list of values.
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []string:
+		if len(v) == 1 {
+			s = v[0]
+		}
+	}
+	if s == "" {
+		return "", fmt.Errorf("invalid %s in %s", name, request.Params.URI)
+	}
+	return s, nil
+}
+
+// jsonResource wraps v as the JSON contents of the resource at uri.
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+func EventsResource(store EventStore) (resource mcp.Resource, handler server.ResourceHandlerFunc) {
+	return mcp.NewResource(EventsResourceURI, "Upcoming OOSA events",
+			mcp.WithResourceDescription("Events that have not ended yet, ordered by start time"),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			upcoming := false
+			events, err := store.GetEvents(ctx, EventFilter{PeriodFilter: PeriodFilter{Past: &upcoming}})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get events: %w", err)
+			}
+			sort.SliceStable(events, func(i, j int) bool {
+				return eventCursorKey(events[i]).less(eventCursorKey(events[j]))
+			})
+
+			return jsonResource(request.Params.URI, events)
+		}
+}
+
+func EventResource(store EventStore) (template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+	return mcp.NewResourceTemplate(EventResourceURITemplate, "OOSA event",
+			mcp.WithTemplateDescription("The complete details of one event, as returned by get_event"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			eventID, err := resourceArg(request, "events_id")
+			if err != nil {
+				return nil, err
+			}
+			// oosa://events/{events_id}.ics matches this template too, so
+			// which handler gets it is up to mcp-go.
+			if id, ok := strings.CutSuffix(eventID, icsSuffix); ok && id != "" {
+				return readEventICS(ctx, store, request.Params.URI, id)
+			}
+
+			event, err := store.GetEvent(ctx, eventID)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return nil, fmt.Errorf("event not found: %s", eventID)
+				}
+				return nil, fmt.Errorf("failed to get event: %w", err)
+			}
+
+			return jsonResource(request.Params.URI, event)
+		}
+}
+
+// HostProfile is the public profile of a user served by the user resource.
+type HostProfile struct {
+	UserProfile
+	UpcomingEvents []Event `json:"user_upcoming_events"`
+}
+
+func UserResource(users UserStore, events EventStore) (template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+	return mcp.NewResourceTemplate(UserResourceURITemplate, "OOSA user",
+			mcp.WithTemplateDescription("The profile of a user: who they are, how many events they hosted and the upcoming events they host"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			userID, err := resourceArg(request, "user_id")
+			if err != nil {
+				return nil, err
+			}
+
+			user, err := users.GetUser(ctx, userID)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return nil, fmt.Errorf("user not found: %s", userID)
+				}
+				return nil, fmt.Errorf("failed to get user: %w", err)
+			}
+
+			all, err := events.GetEvents(ctx, EventFilter{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get events: %w", err)
+			}
+			profile := HostProfile{
+				UserProfile:    UserProfile{UserAgg: *user},
+				UpcomingEvents: []Event{},
+			}
+			now := time.Now()
+			for _, e := range all {
+				if e.CreatedByUser == nil || e.CreatedByUser.ID != userID {
+					continue
+				}
+				profile.EventsHosted++
+				if e.DateEnd.After(now) {
+					profile.UpcomingEvents = append(profile.UpcomingEvents, e)
+				}
+			}
+			sort.SliceStable(profile.UpcomingEvents, func(i, j int) bool {
+				return eventCursorKey(profile.UpcomingEvents[i]).less(eventCursorKey(profile.UpcomingEvents[j]))
+			})
+
+			return jsonResource(request.Params.URI, profile)
+		}
+}
@@ -0,0 +1,41 @@
+package oosa
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestOutputHasNoEmails(t *testing.T) {
+	backend := NewMockClient()
+	events, err := backend.GetEvents(context.Background(), EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eventID, hostID string
+	for _, e := range events {
+		if e.CreatedByUser != nil && e.Participants != nil && len(e.Participants.LatestThreeUser) > 0 {
+			eventID, hostID = e.ID, e.CreatedByUser.ID
+			break
+		}
+	}
+	if eventID == "" {
+		t.Fatal("no mock event with a host and participants")
+	}
+
+	client := newTestClient(t, backend)
+	outputs := map[string]string{
+		"get_events": client.request("tools/call", map[string]any{"name": "get_events", "arguments": map[string]any{"format": "json"}}),
+		"get_event":  client.request("tools/call", map[string]any{"name": "get_event", "arguments": map[string]any{"events_id": eventID}}),
+		"event":      client.request("resources/read", map[string]any{"uri": EventResourceURI(eventID)}),
+		"user":       client.request("resources/read", map[string]any{"uri": UserResourceURI(hostID)}),
+	}
+	for name, out := range outputs {
+		if !strings.Contains(out, hostID) {
+			t.Errorf("%s: host %s missing from %s", name, hostID, out)
+		}
+		if strings.Contains(out, "user_email") || strings.Contains(out, "@example.com") {
+			t.Errorf("%s leaks an email: %s", name, out)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// searchField is an Event field covered by the search index, with the weight
+// a token found in it contributes to the relevance score.
+type searchField struct {
+	weight float64
+	value  func(Event) string
+}
+
+var searchFields = []searchField{
+	{weight: 3, value: func(e Event) string { return e.Name }},
+	{weight: 2, value: func(e Event) string { return e.Place }},
+	{weight: 1, value: func(e Event) string { return e.MeetingPointName }},
+	{weight: 2, value: func(e Event) string { return e.Type }},
+}
+
+// isCJK reports whether r belongs to a script written without spaces, where
+// words are approximated by overlapping bigrams.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo)
+}
+
+// tokenize splits text into search tokens: lower-cased Latin words and digits,
+// and bigrams over runs of CJK characters ("象山步道" -> 象山, 山步, 步道).
+// A CJK run of a single character is kept as is.
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			tokens = append(tokens, string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+// indexedEvent is an event held by the SearchIndex.
+type indexedEvent struct {
+	event  Event
+	digest uint64
+	terms  map[string]float64
+	// gen is the generation of the index the event was last written in.
+	gen uint64
+}
+
+// searchDigest fingerprints the indexed fields of an event, so unchanged
+// events are not re-tokenized.
+func searchDigest(e Event) uint64 {
+	h := fnv.New64a()
+	for _, f := range searchFields {
+		_, _ = h.Write([]byte(f.value(e)))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// SearchIndex is an in-memory inverted index over events. Upsert applies the
+// events written through this server at once; an IndexRefresher fills it
+// and brings in the changes made elsewhere.
+type SearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*indexedEvent
+	postings map[string]map[string]float64 // token -> event ID -> weighted frequency
+	// gen counts the calls to Upsert.
+	gen uint64
+}
+
+// NewSearchIndex creates an empty SearchIndex.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     make(map[string]*indexedEvent),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// Upsert adds an event to the index or refreshes it.
+func (ix *SearchIndex) Upsert(e Event) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.gen++
+	ix.upsert(e, ix.gen)
+}
+
+// Remove drops an event from the index.
+func (ix *SearchIndex) Remove(eventID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(eventID)
+}
+
+// mark and sync let an IndexRefresher keep the index current, see eventIndex.
+func (ix *SearchIndex) mark() uint64 {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.gen
+}
+
+func (ix *SearchIndex) sync(events []Event, mark uint64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	current := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		current[e.ID] = struct{}{}
+		if doc, ok := ix.docs[e.ID]; !ok || doc.gen <= mark {
+			ix.upsert(e, mark)
+		}
+	}
+	for id, doc := range ix.docs {
+		if _, ok := current[id]; !ok && doc.gen <= mark {
+			ix.remove(id)
+		}
+	}
+}
+
+// upsert indexes e as written in generation gen. Callers must hold ix.mu.
+func (ix *SearchIndex) upsert(e Event, gen uint64) {
+	digest := searchDigest(e)
+	if doc, ok := ix.docs[e.ID]; ok && doc.digest == digest {
+		// Only non-indexed fields may have changed, e.g. the remaining seats.
+		doc.event = e
+		doc.gen = gen
+		return
+	}
+	ix.remove(e.ID)
+
+	terms := make(map[string]float64)
+	for _, f := range searchFields {
+		value := f.value(e)
+		for _, token := range tokenize(value) {
+			terms[token] += f.weight
+		}
+		// Single CJK characters are indexed too, at a lower weight, so that
+		// one-character queries such as 湯 still find 泡湯.
+		for _, r := range value {
+			if isCJK(r) {
+				terms[string(r)] += f.weight / 2
+			}
+		}
+	}
+	for token, weight := range terms {
+		if ix.postings[token] == nil {
+			ix.postings[token] = make(map[string]float64)
+		}
+		ix.postings[token][e.ID] = weight
+	}
+	ix.docs[e.ID] = &indexedEvent{event: e, digest: digest, terms: terms, gen: gen}
+}
+
+// remove drops an event. Callers must hold ix.mu.
+func (ix *SearchIndex) remove(eventID string) {
+	doc, ok := ix.docs[eventID]
+	if !ok {
+		return
+	}
+	for token := range doc.terms {
+		delete(ix.postings[token], eventID)
+		if len(ix.postings[token]) == 0 {
+			delete(ix.postings, token)
+		}
+	}
+	delete(ix.docs, eventID)
+}
+
+// SearchResult is an event matching a search, with its relevance score.
+type SearchResult struct {
+	Score float64 `json:"score"`
+	Event Event   `json:"event"`
+}
+
+// Search returns up to limit events matching query, most relevant first.
+//
+// Each query token scores its weighted frequency times its inverse document
+// frequency; the sum is scaled by the share of query tokens the event
+// matched, so events covering the whole query rank above partial matches.
+func (ix *SearchIndex) Search(query string, limit int) []SearchResult {
+	tokens := tokenize(query)
+	unique := make(map[string]struct{}, len(tokens))
+	for _, t := range tokens {
+		unique[t] = struct{}{}
+	}
+	if len(unique) == 0 {
+		return []SearchResult{}
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := float64(len(ix.docs))
+	scores := make(map[string]float64)
+	matched := make(map[string]int)
+	for token := range unique {
+		postings := ix.postings[token]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + n/float64(len(postings)))
+		for id, weight := range postings {
+			scores[id] += weight * idf
+			matched[id]++
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		coverage := float64(matched[id]) / float64(len(unique))
+		results = append(results, SearchResult{
+			Score: math.Round(score*coverage*coverage*1000) / 1000,
+			Event: ix.docs[id].event,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return eventCursorKey(results[i].Event).less(eventCursorKey(results[j].Event))
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// SearchEventsResult is the response of search_events.
+type SearchEventsResult struct {
+	Results    []SearchResult `json:"results"`
+	TotalCount int            `json:"total_count"`
+}
+
+// searchEventsArgs are the arguments of search_events.
+type searchEventsArgs struct {
+	Query string `arg:"query,required" desc:"Search terms, e.g. \"夜景 健行\" or \"老街導覽\""`
+	Limit int    `arg:"limit,min=1,max=50,default=10" desc:"Maximum number of results"`
+}
+
+// SearchEvents searches index, which refresher keeps current.
+func SearchEvents(index *SearchIndex, refresher *IndexRefresher) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("search_events",
+			mcp.WithDescription("Full-text search over OOSA events by name, place, meeting point and type. Handles Chinese text (e.g. 象山 步道, 溫泉) as well as English words. Results are ranked by relevance"),
+			WithArgs(searchEventsArgs{}),
+			WithOutput(),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args searchEventsArgs
+			if err := BindArgs(request, &args); err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+			if strings.TrimSpace(args.Query) == "" || len(tokenize(args.Query)) == 0 {
+				return invalidArgument(errors.New("query must contain at least one word or character")).Result(), nil
+			}
+
+			output, err := OptionalOutputParams(request)
+			if err != nil {
+				return invalidArgument(err).Result(), nil
+			}
+
+			if err := refresher.Refresh(ctx); err != nil {
+				return backendError(err, "failed to get events", "無法取得活動列表").Result(), nil
+			}
+
+			results := index.Search(args.Query, 0)
+			total := len(results)
+			if len(results) > args.Limit {
+				results = results[:args.Limit]
+			}
+
+			return renderResult(SearchEventsResult{
+				Results:    results,
+				TotalCount: total,
+			}, output)
+		}
+}
@@ -0,0 +1,217 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newEventArgs returns create_event arguments for an event a week ahead.
+func newEventArgs(name string) map[string]any {
+	start := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	return map[string]any{
+		"events_name":              name,
+		"events_place":             "陽明山",
+		"events_date":              start.Format(time.RFC3339),
+		"events_date_end":          start.Add(3 * time.Hour).Format(time.RFC3339),
+		"events_deadline":          start.Add(-24 * time.Hour).Format(time.RFC3339),
+		"events_lat":               25.155,
+		"events_lng":               121.548,
+		"events_participant_limit": float64(10),
+		"events_type":              "hiking",
+		"format":                   "json",
+	}
+}
+
+func searchIDs(t *testing.T, client *testClient, query string) []string {
+	t.Helper()
+	var out SearchEventsResult
+	client.callTool("search_events", map[string]any{"query": query, "format": "json"}, &out)
+	ids := make([]string, len(out.Results))
+	for i, r := range out.Results {
+		ids[i] = r.Event.ID
+	}
+	return ids
+}
+
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	backend := &countingBackend{Backend: NewMockClient()}
+	client := newTestClient(t, backend)
+	host := client.as("user1")
+
+	if ids := searchIDs(t, client, "螢火蟲"); len(ids) != 0 {
+		t.Fatalf("found %v before the event exists", ids)
+	}
+
+	var created EventDetail
+	host.callTool("create_event", newEventArgs("螢火蟲夜遊"), &created)
+	if ids := searchIDs(t, client, "螢火蟲"); !slices.Equal(ids, []string{created.ID}) {
+		t.Errorf("after create: %v", ids)
+	}
+
+	host.callTool("update_event", map[string]any{"events_id": created.ID, "events_name": "油桐花步道"}, nil)
+	if ids := searchIDs(t, client, "螢火蟲"); len(ids) != 0 {
+		t.Errorf("old name still found: %v", ids)
+	}
+	if ids := searchIDs(t, client, "油桐花"); !slices.Equal(ids, []string{created.ID}) {
+		t.Errorf("after update: %v", ids)
+	}
+
+	client.as("user2").callTool("join_event", map[string]any{"events_id": created.ID}, nil)
+	var out SearchEventsResult
+	client.callTool("search_events", map[string]any{"query": "油桐花", "format": "json"}, &out)
+	if remain := out.Results[0].Event.Participants.RemainNumber; remain != 9 {
+		t.Errorf("remaining seats %d after a join, want 9", remain)
+	}
+
+	if n := backend.scans.Load(); n != 1 {
+		t.Errorf("%d full scans, want 1", n)
+	}
+}
+
+func TestSearchIndexScansOnce(t *testing.T) {
+	backend := &countingBackend{Backend: NewMockClient()}
+	client := newTestClient(t, backend)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			searchIDs(t, client, "步道")
+		}()
+	}
+	wg.Wait()
+
+	if n := backend.scans.Load(); n != 1 {
+		t.Errorf("%d full scans, want 1", n)
+	}
+}
+
+// flakyStore fails its first scan, and calls during while it runs the
+// others.
+type flakyStore struct {
+	EventStore
+	failed bool
+	during func()
+}
+
+func (s *flakyStore) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	if !s.failed {
+		s.failed = true
+		return nil, errors.New("connection refused")
+	}
+	if s.during != nil {
+		s.during()
+	}
+	return s.EventStore.GetEvents(ctx, filter)
+}
+
+func TestIndexRefresherScans(t *testing.T) {
+	index := NewSearchIndex()
+	store := &flakyStore{EventStore: NewMockClient()}
+	clock := newTestClock()
+	refresher := NewIndexRefresher(store, time.Minute, clock.Now, index)
+
+	if err := refresher.Refresh(context.Background()); err == nil {
+		t.Fatal("failed scan reported no error")
+	}
+	// A write made while the scan runs is newer than what the scan reads.
+	store.during = func() { index.Upsert(Event{ID: "new", Name: "螢火蟲"}) }
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Search("步道", 0)) == 0 {
+		t.Error("scan did not fill the index")
+	}
+	if results := index.Search("螢火蟲", 0); len(results) != 1 || results[0].Event.ID != "new" {
+		t.Errorf("event upserted during the scan lost: %+v", results)
+	}
+
+	// The next scan finds it missing from the backend.
+	store.during = nil
+	clock.advance(time.Minute)
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if results := index.Search("螢火蟲", 0); len(results) != 0 {
+		t.Errorf("event missing from the backend kept: %+v", results)
+	}
+}
+
+// testClock is a clock tests move by hand.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Now()}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// outsideBackend stands for a backend others write to as well: events are
+// added to it directly, bypassing the server, and can be deleted.
+type outsideBackend struct {
+	Backend
+	mu      sync.Mutex
+	deleted map[string]bool
+}
+
+func newOutsideBackend() *outsideBackend {
+	return &outsideBackend{Backend: NewMockClient(), deleted: make(map[string]bool)}
+}
+
+func (b *outsideBackend) delete(eventID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deleted[eventID] = true
+}
+
+func (b *outsideBackend) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	events, err := b.Backend.GetEvents(ctx, filter)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.DeleteFunc(events, func(e Event) bool { return b.deleted[e.ID] }), err
+}
+
+// insert creates an event straight in the backend.
+func (b *outsideBackend) insert(t *testing.T, e Event) *EventDetail {
+	t.Helper()
+	created, err := b.Backend.CreateEvent(context.Background(), EventDetail{Event: e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func TestSearchIndexSeesOutsideChanges(t *testing.T) {
+	backend := newOutsideBackend()
+	clock := newTestClock()
+	client := newTestClient(t, backend, WithClock(clock.Now), WithIndexRefresh(time.Minute))
+	if ids := searchIDs(t, client, "象山"); !slices.Equal(ids, []string{"event1"}) {
+		t.Fatalf("before the changes: %v", ids)
+	}
+
+	created := backend.insert(t, Event{Name: "象山夜景", Type: "hiking"})
+	backend.delete("event1")
+	// The index may lag behind by the refresh interval, no more.
+	clock.advance(time.Minute)
+	if ids := searchIDs(t, client, "象山"); !slices.Equal(ids, []string{created.ID}) {
+		t.Errorf("after the changes: %v, want %v", ids, []string{created.ID})
+	}
+}
@@ -1,33 +1,147 @@
 package oosa
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func NewServer(client *Client, version string) *server.MCPServer {
+// ServerOption configures the server built by NewServer.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	bounds        Bounds
+	now           func() time.Time
+	indexRefresh  time.Duration
+	subscriptions *Subscriptions
+	drain         *Drain
+}
+
+// WithBounds sets the area new events must lie in. DefaultBounds is used otherwise.
+func WithBounds(b Bounds) ServerOption {
+	return func(o *serverOptions) {
+		o.bounds = b
+	}
+}
+
+// WithClock sets the clock new events are checked against. time.Now is used
+// otherwise.
+func WithClock(now func() time.Time) ServerOption {
+	return func(o *serverOptions) {
+		o.now = now
+	}
+}
+
+// WithIndexRefresh sets how old the search and nearby indexes may get before
+// a query rescans the backend for changes made outside this server.
+// DefaultIndexRefreshInterval is used otherwise.
+func WithIndexRefresh(d time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.indexRefresh = d
+	}
+}
+
+func NewServer(backend Backend, version string, opts ...ServerOption) *server.MCPServer {
+	o := serverOptions{bounds: DefaultBounds, now: time.Now, indexRefresh: DefaultIndexRefreshInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	serverOpts := []server.ServerOption{
+		server.WithResourceCapabilities(true, true),
+		server.WithLogging(),
+	}
+	// The indexes see every event written through the tools at once, and
+	// the other changes on the next refresh.
+	search := NewSearchIndex()
+	grid := NewGeoIndex()
+	refresher := NewIndexRefresher(backend, o.indexRefresh, o.now, search, grid)
+	observers := []func(Event){search.Upsert, grid.Upsert}
+
+	hooks := &server.Hooks{}
+	if o.subscriptions != nil {
+		hooks.AddOnRegisterSession(o.subscriptions.registerSession)
+		subs := o.subscriptions
+		observers = append(observers, func(e Event) { subs.observe(e, true) })
+	}
+	backend = notifyingBackend{Backend: backend, observers: observers}
+	if o.drain != nil {
+		hooks.AddOnRegisterSession(o.drain.registerSession)
+	}
+	serverOpts = append(serverOpts, server.WithHooks(hooks))
+
 	// Create a new MCP server
 	s := server.NewMCPServer(
 		"oosa-mcp-server",
 		version,
-		server.WithResourceCapabilities(true, true),
-		server.WithLogging())
+		serverOpts...)
 
 	// Add resources
-	// TODO: Add resources
+	s.AddResource(EventsResource(backend))
+	s.AddResourceTemplate(EventResource(backend))
+	s.AddResourceTemplate(EventICSResource(backend))
+	s.AddResourceTemplate(UserResource(backend, backend))
+	s.AddResource(CategoriesResource())
 
 	// Add tools
-	s.AddTool(GetEvents(client))
-	// s.AddTool(GetIdeas(client))
+	s.AddTool(GetEvents(backend))
+	s.AddTool(GetEvent(backend))
+	s.AddTool(SearchEvents(search, refresher))
+	s.AddTool(FindEventsNearby(grid, refresher, o.now))
+	s.AddTool(CreateEvent(backend, backend, o.bounds, o.now))
+	s.AddTool(UpdateEvent(backend, backend, backend, o.bounds, o.now))
+	s.AddTool(CancelEvent(backend, backend, backend))
+	s.AddTool(JoinEvent(backend, backend))
+	s.AddTool(LeaveEvent(backend, backend))
+	s.AddTool(ExportEventsICS(backend))
+	s.AddTool(GetIdeas(backend))
 
 	// Add prompts
-	// TODO: Add prompts
+	s.AddPrompt(PlanOuting())
+	s.AddPrompt(DraftEventAnnouncement(backend))
 
 	return s
 }
 
+// notifyingBackend passes the events changed through its EventWriter
+// methods to observers.
+type notifyingBackend struct {
+	Backend
+	observers []func(Event)
+}
+
+func (b notifyingBackend) observe(event *EventDetail, err error) (*EventDetail, error) {
+	if err == nil && event != nil {
+		for _, observe := range b.observers {
+			observe(event.Event)
+		}
+	}
+	return event, err
+}
+
+func (b notifyingBackend) CreateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	return b.observe(b.Backend.CreateEvent(ctx, event))
+}
+
+func (b notifyingBackend) UpdateEvent(ctx context.Context, event EventDetail) (*EventDetail, error) {
+	return b.observe(b.Backend.UpdateEvent(ctx, event))
+}
+
+func (b notifyingBackend) CancelEvent(ctx context.Context, eventID string, reason string) (*EventDetail, error) {
+	return b.observe(b.Backend.CancelEvent(ctx, eventID, reason))
+}
+
+func (b notifyingBackend) JoinEvent(ctx context.Context, eventID string, user UserAgg) (*EventDetail, error) {
+	return b.observe(b.Backend.JoinEvent(ctx, eventID, user))
+}
+
+func (b notifyingBackend) LeaveEvent(ctx context.Context, eventID string, userID string) (*EventDetail, error) {
+	return b.observe(b.Backend.LeaveEvent(ctx, eventID, userID))
+}
+
 // requiredParam is a helper function that can be used to fetch a requested parameter from the request.
 // It does the following checks:
 // 1. Checks if the parameter is present in the request.
@@ -140,13 +254,13 @@ func OptionalStringArrayParam(r mcp.CallToolRequest, p string) ([]string, error)
 	}
 }
 
-// WithPagination returns a ToolOption that adds "page" and "perPage" parameters to the tool.
-// The "page" parameter is optional, min 1. The "perPage" parameter is optional, min 1, max 100.
+// WithPagination returns a ToolOption that adds "cursor" and "perPage" parameters to the tool.
+// The "cursor" parameter is optional and takes the next_cursor of a previous page.
+// The "perPage" parameter is optional, min 1, max 100.
 func WithPagination() mcp.ToolOption {
 	return func(tool *mcp.Tool) {
-		mcp.WithNumber("page",
-			mcp.Description("Page number for pagination (min 1)"),
-			mcp.Min(1),
+		mcp.WithString("cursor",
+			mcp.Description("Opaque cursor from the next_cursor of a previous page; omit to start from the first page"),
 		)(tool)
 
 		mcp.WithNumber("perPage",
@@ -158,17 +272,17 @@ func WithPagination() mcp.ToolOption {
 }
 
 type PaginationParams struct {
-	page    int
-	perPage int
+	Cursor  string
+	PerPage int
 }
 
-// OptionalPaginationParams returns the "page" and "perPage" parameters from the request,
-// or their default values if not present, "page" default is 1, "perPage" default is 30.
+// OptionalPaginationParams returns the "cursor" and "perPage" parameters from the request,
+// or their default values if not present, "cursor" default is empty (first page), "perPage" default is 30.
 // In future, we may want to make the default values configurable, or even have this
 // function returned from `withPagination`, where the defaults are provided alongside
 // the min/max values.
 func OptionalPaginationParams(r mcp.CallToolRequest) (PaginationParams, error) {
-	page, err := OptionalIntParamWithDefault(r, "page", 1)
+	cursor, err := OptionalParam[string](r, "cursor")
 	if err != nil {
 		return PaginationParams{}, err
 	}
@@ -176,8 +290,11 @@ func OptionalPaginationParams(r mcp.CallToolRequest) (PaginationParams, error) {
 	if err != nil {
 		return PaginationParams{}, err
 	}
+	if perPage < 1 || perPage > 100 {
+		return PaginationParams{}, fmt.Errorf("perPage must be between 1 and 100, is %d", perPage)
+	}
 	return PaginationParams{
-		page:    page,
-		perPage: perPage,
+		Cursor:  cursor,
+		PerPage: perPage,
 	}, nil
 }
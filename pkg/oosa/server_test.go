@@ -0,0 +1,83 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testClient sends JSON-RPC requests to an in-process server.
+type testClient struct {
+	t   *testing.T
+	srv *server.MCPServer
+	ctx context.Context
+}
+
+func newTestClient(t *testing.T, backend Backend, opts ...ServerOption) *testClient {
+	return &testClient{t: t, srv: NewServer(backend, "test", opts...), ctx: context.Background()}
+}
+
+// as returns a client calling on behalf of userID.
+func (c *testClient) as(userID string) *testClient {
+	return &testClient{t: c.t, srv: c.srv, ctx: WithCallerID(c.ctx, userID)}
+}
+
+// request sends a request and returns the response as JSON.
+func (c *testClient) request(method string, params any) string {
+	c.t.Helper()
+	message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	response, err := json.Marshal(c.srv.HandleMessage(c.ctx, message))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return string(response)
+}
+
+// callTool calls a tool that must succeed and decodes its JSON output into v.
+func (c *testClient) callTool(name string, args map[string]any, v any) {
+	c.t.Helper()
+	text, isError := c.tryTool(name, args)
+	if isError {
+		c.t.Fatalf("%s failed: %s", name, text)
+	}
+	if v != nil {
+		if err := json.Unmarshal([]byte(text), v); err != nil {
+			c.t.Fatalf("%s: %v in %s", name, err, text)
+		}
+	}
+}
+
+// tryTool calls a tool and returns the text of its result.
+func (c *testClient) tryTool(name string, args map[string]any) (text string, isError bool) {
+	c.t.Helper()
+	var response struct {
+		Result *struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+		Error any `json:"error"`
+	}
+	raw := c.request("tools/call", map[string]any{"name": name, "arguments": args})
+	if err := json.Unmarshal([]byte(raw), &response); err != nil || response.Result == nil || len(response.Result.Content) == 0 {
+		c.t.Fatalf("%s: %v %s", name, err, raw)
+	}
+	return response.Result.Content[0].Text, response.Result.IsError
+}
+
+// countingBackend counts the full scans of its events.
+type countingBackend struct {
+	Backend
+	scans atomic.Int32
+}
+
+func (b *countingBackend) GetEvents(ctx context.Context, filter EventFilter) ([]Event, error) {
+	b.scans.Add(1)
+	return b.Backend.GetEvents(ctx, filter)
+}
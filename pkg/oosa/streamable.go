@@ -0,0 +1,722 @@
+package oosa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Streamable HTTP is the MCP transport that replaces HTTP+SSE. A single
+// endpoint takes every client message as a POST and answers either with JSON
+// or with an event stream. A GET on it opens a stream for the messages the
+// server sends on its own, and a DELETE ends the session. mcp-go only serves
+// the older SSE transport, so StreamableHTTPServer hands the messages to the
+// MCP server itself.
+
+const (
+	headerSessionID   = "Mcp-Session-Id"
+	headerLastEventID = "Last-Event-ID"
+
+	// DefaultEndpointPath is where a StreamableHTTPServer listens.
+	DefaultEndpointPath = "/mcp"
+	// DefaultSessionIdleTimeout is how long a StreamableHTTPServer keeps a
+	// session nobody uses.
+	DefaultSessionIdleTimeout = 30 * time.Minute
+
+	// standaloneStream is the stream opened by GET, carrying the
+	// notifications of a session.
+	standaloneStream = "0"
+	// streamHistorySize is how many events of a session are kept for clients
+	// resuming a broken stream.
+	streamHistorySize = 256
+	// streamBuffer is how many events may wait for a slow client before its
+	// stream is dropped. It can resume with Last-Event-ID.
+	streamBuffer = 64
+)
+
+var (
+	errUnknownStream = errors.New("unknown stream")
+	errStreamOpen    = errors.New("the stream is already open")
+)
+
+// StreamableHTTPServer serves an MCP server over the streamable HTTP
+// transport. It is an http.Handler for its endpoint.
+type StreamableHTTPServer struct {
+	server       *server.MCPServer
+	endpointPath string
+	contextFunc  server.SSEContextFunc
+	idleTimeout  time.Duration
+	// intercept answers the messages the MCP server cannot, see
+	// Subscriptions.StreamableHTTP.
+	intercept func(sessionID string, message []byte) (mcp.JSONRPCMessage, bool)
+
+	// ctx is the parent of every session context; cancel ends them all.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// StreamableHTTPOption configures a StreamableHTTPServer.
+type StreamableHTTPOption func(*StreamableHTTPServer)
+
+// WithEndpointPath sets the path of the endpoint, DefaultEndpointPath by default.
+func WithEndpointPath(path string) StreamableHTTPOption {
+	return func(s *StreamableHTTPServer) {
+		s.endpointPath = path
+	}
+}
+
+// WithHTTPContextFunc sets a function that adds values from each HTTP
+// request, such as the caller, to the context of the messages it carries.
+func WithHTTPContextFunc(fn server.SSEContextFunc) StreamableHTTPOption {
+	return func(s *StreamableHTTPServer) {
+		s.contextFunc = fn
+	}
+}
+
+// WithSessionIdleTimeout sets how long a session may go without requests or
+// open streams before it is closed. Zero keeps sessions until the client
+// deletes them.
+func WithSessionIdleTimeout(d time.Duration) StreamableHTTPOption {
+	return func(s *StreamableHTTPServer) {
+		s.idleTimeout = d
+	}
+}
+
+func NewStreamableHTTPServer(mcpServer *server.MCPServer, opts ...StreamableHTTPOption) *StreamableHTTPServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &StreamableHTTPServer{
+		server:       mcpServer,
+		endpointPath: DefaultEndpointPath,
+		idleTimeout:  DefaultSessionIdleTimeout,
+		ctx:          ctx,
+		cancel:       cancel,
+		sessions:     make(map[string]*httpSession),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.idleTimeout > 0 {
+		go s.expireSessions()
+	}
+	return s
+}
+
+// Shutdown closes every session, ending their streams.
+func (s *StreamableHTTPServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	sessions := make([]*httpSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mu.Unlock()
+
+	for _, session := range sessions {
+		s.closeSession(session)
+	}
+	s.cancel()
+	return ctx.Err()
+}
+
+func (s *StreamableHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.endpointPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleGet(w, r)
+	case http.MethodDelete:
+		s.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeHTTPError(w, http.StatusMethodNotAllowed, mcp.INVALID_REQUEST, "method not allowed")
+	}
+}
+
+// clientMessage is a JSON-RPC message posted by the client.
+type clientMessage struct {
+	raw    json.RawMessage
+	method string
+	// request is set for requests, which are the messages expecting a response.
+	request bool
+}
+
+// parseClientMessages splits a POST body into its messages. batch reports
+// whether they came as a JSON array.
+func parseClientMessages(body []byte) (messages []clientMessage, batch bool, err error) {
+	var raws []json.RawMessage
+	body = bytes.TrimSpace(body)
+	if batch = bytes.HasPrefix(body, []byte("[")); batch {
+		if err := json.Unmarshal(body, &raws); err != nil {
+			return nil, false, err
+		}
+		if len(raws) == 0 {
+			return nil, false, errors.New("empty batch")
+		}
+	} else {
+		raws = []json.RawMessage{body}
+	}
+
+	for _, raw := range raws {
+		var header struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, false, err
+		}
+		messages = append(messages, clientMessage{
+			raw:     raw,
+			method:  header.Method,
+			request: header.Method != "" && len(header.ID) > 0 && string(header.ID) != "null",
+		})
+	}
+	return messages, batch, nil
+}
+
+func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "failed to read request body")
+		return
+	}
+	if len(body) > maxMessageSize {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, mcp.INVALID_REQUEST, "message too large")
+		return
+	}
+	messages, batch, err := parseClientMessages(body)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "parse error: "+err.Error())
+		return
+	}
+
+	var session *httpSession
+	initialize := false
+	for _, m := range messages {
+		initialize = initialize || m.method == string(mcp.MethodInitialize)
+	}
+	if initialize {
+		if batch {
+			writeHTTPError(w, http.StatusBadRequest, mcp.INVALID_REQUEST, "initialize must not be part of a batch")
+			return
+		}
+		if session, err = s.newSession(); err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, mcp.INTERNAL_ERROR, err.Error())
+			return
+		}
+		w.Header().Set(headerSessionID, session.id)
+	} else if session = s.lookupSession(w, r); session == nil {
+		return
+	}
+
+	ctx := s.server.WithContext(session.ctx, session)
+	if s.contextFunc != nil {
+		ctx = s.contextFunc(ctx, r)
+	}
+
+	requests := 0
+	for _, m := range messages {
+		if m.request {
+			requests++
+		}
+	}
+	if requests == 0 {
+		for _, m := range messages {
+			s.handleMessage(ctx, session, m)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if !acceptsEventStream(r) {
+		var responses []mcp.JSONRPCMessage
+		for _, m := range messages {
+			if response := s.handleMessage(ctx, session, m); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if batch {
+			_ = json.NewEncoder(w).Encode(responses)
+		} else {
+			_ = json.NewEncoder(w).Encode(responses[0])
+		}
+		return
+	}
+
+	// The responses are sent on a stream of their own. They are worked out
+	// apart from the connection, so that a client whose connection breaks can
+	// still fetch them by resuming the stream. A shutdown waits for them.
+	stream := session.newStream()
+	replay, live, _ := session.attach(stream, nil)
+	release := holdDrain(r.Context())
+	go func() {
+		defer release()
+		defer session.finish(stream)
+		for _, m := range messages {
+			if response := s.handleMessage(ctx, session, m); response != nil {
+				session.send(stream, response)
+			}
+		}
+	}()
+	writeEvents(w, r, session, stream, replay, live)
+}
+
+// handleMessage passes one client message to the MCP server and returns the
+// response to send, if any.
+func (s *StreamableHTTPServer) handleMessage(ctx context.Context, session *httpSession, m clientMessage) mcp.JSONRPCMessage {
+	// Responses to server requests are dropped: this server sends none.
+	if m.method == "" {
+		return nil
+	}
+	session.touch()
+	defer session.touch()
+
+	if s.intercept != nil {
+		if response, ok := s.intercept(session.id, m.raw); ok {
+			return response
+		}
+	}
+	response := s.server.HandleMessage(ctx, m.raw)
+	if !m.request {
+		return nil
+	}
+	return response
+}
+
+func (s *StreamableHTTPServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		writeHTTPError(w, http.StatusNotAcceptable, mcp.INVALID_REQUEST, "GET must accept text/event-stream")
+		return
+	}
+	session := s.lookupSession(w, r)
+	if session == nil {
+		return
+	}
+
+	// Last-Event-ID resumes the stream it names after that event; without it
+	// this is the notification stream, starting with what nobody received.
+	stream := standaloneStream
+	var after *uint64
+	if lastEventID := r.Header.Get(headerLastEventID); lastEventID != "" {
+		var seq uint64
+		var ok bool
+		if stream, seq, ok = parseEventID(lastEventID); !ok {
+			writeHTTPError(w, http.StatusBadRequest, mcp.INVALID_REQUEST, fmt.Sprintf("invalid %s %q", headerLastEventID, lastEventID))
+			return
+		}
+		after = &seq
+	}
+
+	replay, live, err := session.attach(stream, after)
+	switch {
+	case errors.Is(err, errUnknownStream):
+		writeHTTPError(w, http.StatusNotFound, mcp.INVALID_REQUEST, "the stream to resume no longer exists")
+		return
+	case errors.Is(err, errStreamOpen):
+		writeHTTPError(w, http.StatusConflict, mcp.INVALID_REQUEST, err.Error())
+		return
+	}
+	writeEvents(w, r, session, stream, replay, live)
+}
+
+func (s *StreamableHTTPServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session := s.lookupSession(w, r)
+	if session == nil {
+		return
+	}
+	s.closeSession(session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *StreamableHTTPServer) newSession() (*httpSession, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("failed to create session ID: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	session := &httpSession{
+		id:            hex.EncodeToString(id[:]),
+		notifications: make(chan mcp.JSONRPCNotification, 100),
+		ctx:           ctx,
+		cancel:        cancel,
+		lastSeen:      time.Now(),
+		streams:       map[string]*eventStream{standaloneStream: {}},
+	}
+	if err := s.server.RegisterSession(ctx, session); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to register session: %w", err)
+	}
+
+	s.mu.Lock()
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+
+	go session.forwardNotifications()
+	return session, nil
+}
+
+// lookupSession returns the session named by the request, or writes the
+// error and returns nil.
+func (s *StreamableHTTPServer) lookupSession(w http.ResponseWriter, r *http.Request) *httpSession {
+	id := r.Header.Get(headerSessionID)
+	if id == "" {
+		writeHTTPError(w, http.StatusBadRequest, mcp.INVALID_REQUEST, "missing "+headerSessionID+" header")
+		return nil
+	}
+
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		// 404 tells the client to start a new session.
+		writeHTTPError(w, http.StatusNotFound, mcp.INVALID_REQUEST, "unknown or expired session "+id)
+		return nil
+	}
+	session.touch()
+	return session
+}
+
+func (s *StreamableHTTPServer) closeSession(session *httpSession) {
+	s.mu.Lock()
+	delete(s.sessions, session.id)
+	s.mu.Unlock()
+
+	session.cancel()
+	s.server.UnregisterSession(session.id)
+}
+
+// expireSessions closes idle sessions until the server shuts down.
+func (s *StreamableHTTPServer) expireSessions() {
+	ticker := time.NewTicker(min(s.idleTimeout/2, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			var idle []*httpSession
+			s.mu.Lock()
+			for _, session := range s.sessions {
+				if session.idleSince(now.Add(-s.idleTimeout)) {
+					idle = append(idle, session)
+				}
+			}
+			s.mu.Unlock()
+
+			for _, session := range idle {
+				s.closeSession(session)
+			}
+		}
+	}
+}
+
+// acceptsEventStream reports whether the client takes text/event-stream
+// responses.
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeHTTPError fails a request with a JSON-RPC error that answers no
+// message in particular.
+func writeHTTPError(w http.ResponseWriter, status, code int, message string) {
+	response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION}
+	response.Error.Code = code
+	response.Error.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// writeEvents writes the events of a stream as a text/event-stream: those in
+// replay, then those from live until it is closed, the client goes away or
+// the session ends.
+func writeEvents(w http.ResponseWriter, r *http.Request, session *httpSession, stream string, replay []streamEvent, live <-chan streamEvent) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		session.detach(stream, live)
+		writeHTTPError(w, http.StatusInternalServerError, mcp.INTERNAL_ERROR, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, ev := range replay {
+		fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", ev.eventID(), ev.data)
+	}
+	flusher.Flush()
+
+	if live == nil {
+		return
+	}
+	for {
+		select {
+		case ev, ok := <-live:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", ev.eventID(), ev.data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			session.detach(stream, live)
+			return
+		case <-session.ctx.Done():
+			return
+		}
+	}
+}
+
+// httpSession is a session of a StreamableHTTPServer. Every message sent to
+// the client is an event numbered within the session, and the last ones are
+// kept so that a client whose stream broke can fetch what it missed.
+type httpSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	mu         sync.Mutex
+	lastSeen   time.Time
+	lastEvent  uint64
+	lastStream uint64
+	history    []streamEvent
+	streams    map[string]*eventStream
+}
+
+// streamEvent is a message sent to the client on a stream.
+type streamEvent struct {
+	seq    uint64
+	stream string
+	data   []byte
+}
+
+// eventStream is a sequence of events of a session: the notifications, or
+// the responses to one POST.
+type eventStream struct {
+	// live receives the events while a connection is reading the stream.
+	live chan streamEvent
+	// delivered is the last event handed to a connection.
+	delivered uint64
+	// kept counts the events of the stream in the session history.
+	kept int
+	// done is set once every event of the stream has been sent.
+	done bool
+}
+
+// eventID identifies ev to the client. It names the stream, so that
+// Last-Event-ID tells which stream to resume.
+func (ev streamEvent) eventID() string {
+	return ev.stream + "-" + strconv.FormatUint(ev.seq, 10)
+}
+
+func parseEventID(s string) (stream string, seq uint64, ok bool) {
+	stream, n, ok := strings.Cut(s, "-")
+	if !ok || stream == "" {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(n, 10, 64)
+	return stream, seq, err == nil
+}
+
+func (s *httpSession) SessionID() string {
+	return s.id
+}
+
+func (s *httpSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *httpSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+func (s *httpSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+var _ server.ClientSession = (*httpSession)(nil)
+
+// forwardNotifications sends the notifications of the session on its
+// standalone stream until the session ends.
+func (s *httpSession) forwardNotifications() {
+	for {
+		select {
+		case notification := <-s.notifications:
+			s.send(standaloneStream, notification)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *httpSession) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSeen = time.Now()
+}
+
+// idleSince reports whether the session has had no request since t and no
+// stream is being read.
+func (s *httpSession) idleSince(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range s.streams {
+		if st.live != nil {
+			return false
+		}
+	}
+	return s.lastSeen.Before(t)
+}
+
+// newStream starts a stream for the responses to a POST.
+func (s *httpSession) newStream() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastStream++
+	id := strconv.FormatUint(s.lastStream, 10)
+	s.streams[id] = &eventStream{}
+	return id
+}
+
+// send adds message to a stream, handing it to the connection reading the
+// stream, if any.
+func (s *httpSession) send(stream string, message any) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[stream]
+	if !ok {
+		return
+	}
+	s.lastEvent++
+	ev := streamEvent{seq: s.lastEvent, stream: stream, data: data}
+	s.history = append(s.history, ev)
+	st.kept++
+	if len(s.history) > streamHistorySize {
+		s.forget(s.history[0])
+		s.history = s.history[1:]
+	}
+
+	if st.live != nil {
+		select {
+		case st.live <- ev:
+			st.delivered = ev.seq
+		default:
+			// The client is not keeping up. Dropping its connection lets it
+			// resume from the last event it got.
+			close(st.live)
+			st.live = nil
+		}
+	}
+}
+
+// forget drops ev from the history, and its stream once it has no events
+// left to fetch. Called with s.mu held.
+func (s *httpSession) forget(ev streamEvent) {
+	st := s.streams[ev.stream]
+	st.kept--
+	if st.kept == 0 && st.done && st.live == nil {
+		delete(s.streams, ev.stream)
+	}
+}
+
+// attach connects a reader to a stream. It returns the kept events after
+// after, or after the last delivered one when after is nil, and the channel
+// of the events to come, which is nil when the stream is done.
+func (s *httpSession) attach(stream string, after *uint64) ([]streamEvent, <-chan streamEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[stream]
+	if !ok {
+		return nil, nil, errUnknownStream
+	}
+	if st.live != nil {
+		return nil, nil, errStreamOpen
+	}
+
+	from := st.delivered
+	if after != nil {
+		from = *after
+	}
+	var replay []streamEvent
+	for _, ev := range s.history {
+		if ev.stream == stream && ev.seq > from {
+			replay = append(replay, ev)
+			st.delivered = ev.seq
+		}
+	}
+	if st.done {
+		return replay, nil, nil
+	}
+	st.live = make(chan streamEvent, streamBuffer)
+	return replay, st.live, nil
+}
+
+// detach disconnects the reader of live from its stream.
+func (s *httpSession) detach(stream string, live <-chan streamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.streams[stream]; ok && st.live != nil && (<-chan streamEvent)(st.live) == live {
+		st.live = nil
+	}
+}
+
+// finish marks a stream as complete, ending the connection reading it.
+func (s *httpSession) finish(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[stream]
+	if !ok {
+		return
+	}
+	st.done = true
+	if st.live != nil {
+		close(st.live)
+		st.live = nil
+	}
+	if st.kept == 0 {
+		delete(s.streams, stream)
+	}
+}
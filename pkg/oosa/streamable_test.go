@@ -0,0 +1,227 @@
+package oosa
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	initializeMessage = `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+	getEventsMessage  = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_events","arguments":{}}}`
+)
+
+// streamableClient talks to a StreamableHTTPServer over HTTP.
+type streamableClient struct {
+	t       *testing.T
+	url     string
+	session string
+}
+
+// newStreamableClient serves handler and starts a session on it.
+func newStreamableClient(t *testing.T, handler http.Handler) *streamableClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := &streamableClient{t: t, url: srv.URL + DefaultEndpointPath}
+	resp := c.do(context.Background(), http.MethodPost, initializeMessage, "application/json", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize: status %d", resp.StatusCode)
+	}
+	c.session = resp.Header.Get(headerSessionID)
+	return c
+}
+
+// do sends a request in the session; body is sent when not empty.
+func (c *streamableClient) do(ctx context.Context, method, body, accept string, header http.Header) *http.Response {
+	c.t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Content-Type", "application/json")
+	if c.session != "" {
+		req.Header.Set(headerSessionID, c.session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return resp
+}
+
+// notifyServed wraps handler to tell on served when it is done with a POST.
+func notifyServed(handler http.Handler) (wrapped http.Handler, served <-chan struct{}) {
+	done := make(chan struct{}, 4)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		if r.Method == http.MethodPost {
+			done <- struct{}{}
+		}
+	}), done
+}
+
+// sseEvent is an event read from a text/event-stream.
+type sseEvent struct {
+	id, data string
+}
+
+// readEvents reads the events of a stream until it ends.
+func readEvents(body io.ReadCloser) <-chan sseEvent {
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		defer func() { _ = body.Close() }()
+		var ev sseEvent
+		lines := bufio.NewScanner(body)
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && ev.data != "":
+				events <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent waits for the next event of a stream.
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	return sseEvent{}
+}
+
+func TestStreamableResumesStreams(t *testing.T) {
+	backend := &blockingBackend{Backend: NewMockClient(), started: make(chan struct{}), release: make(chan struct{})}
+	streamable := NewStreamableHTTPServer(NewServer(backend, "test"))
+	t.Cleanup(func() { _ = streamable.Shutdown(context.Background()) })
+	handler, served := notifyServed(streamable)
+	client := newStreamableClient(t, handler)
+	<-served
+
+	// The connection breaks after the first answer of the batch, while the
+	// second is still being worked out.
+	const batch = `[{"jsonrpc":"2.0","id":2,"method":"ping"},` + getEventsMessage + `]`
+	ctx, hangUp := context.WithCancel(context.Background())
+	resp := client.do(ctx, http.MethodPost, batch, "text/event-stream", nil)
+	first := nextEvent(t, readEvents(resp.Body))
+	if !strings.Contains(first.data, `"id":2`) {
+		t.Fatalf("got %s, want the ping answer first", first.data)
+	}
+	<-backend.started
+	hangUp()
+	<-served
+	close(backend.release)
+
+	resume := func(lastEventID string) *http.Response {
+		t.Helper()
+		return client.do(context.Background(), http.MethodGet, "", "text/event-stream", http.Header{headerLastEventID: {lastEventID}})
+	}
+	resp = resume(first.id)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resume after %s: status %d", first.id, resp.StatusCode)
+	}
+	events := readEvents(resp.Body)
+	if ev := nextEvent(t, events); !strings.Contains(ev.data, `"id":1,"result"`) {
+		t.Errorf("resumed with %s %s, want the get_events answer", ev.id, ev.data)
+	}
+	// The stream is complete, so it ends after the replay.
+	if ev, ok := <-events; ok {
+		t.Errorf("stream went on with %s %s", ev.id, ev.data)
+	}
+
+	resp = resume("9-0")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("resume of an unknown stream: status %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableExpiresIdleSessions(t *testing.T) {
+	const idle = 20 * time.Millisecond
+	streamable := NewStreamableHTTPServer(NewServer(NewMockClient(), "test"), WithSessionIdleTimeout(idle))
+	t.Cleanup(func() { _ = streamable.Shutdown(context.Background()) })
+	client := newStreamableClient(t, streamable)
+	open := func() bool {
+		streamable.mu.Lock()
+		defer streamable.mu.Unlock()
+		_, ok := streamable.sessions[client.session]
+		return ok
+	}
+
+	// A session with a stream being read is in use, however quiet.
+	ctx, closeStream := context.WithCancel(context.Background())
+	resp := client.do(ctx, http.MethodGet, "", "text/event-stream", nil)
+	events := readEvents(resp.Body)
+	time.Sleep(10 * idle)
+	if !open() {
+		t.Fatal("session with an open stream expired")
+	}
+
+	closeStream()
+	for range events {
+	}
+	for deadline := time.Now().Add(5 * time.Second); open(); time.Sleep(idle) {
+		if time.Now().After(deadline) {
+			t.Fatal("idle session did not expire")
+		}
+	}
+	resp = client.do(context.Background(), http.MethodPost, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, "application/json", nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST to an expired session: status %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableShutdownEndsSessions(t *testing.T) {
+	streamable := NewStreamableHTTPServer(NewServer(NewMockClient(), "test"))
+	client := newStreamableClient(t, streamable)
+	resp := client.do(context.Background(), http.MethodGet, "", "text/event-stream", nil)
+	events := readEvents(resp.Body)
+
+	if err := streamable.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Errorf("got %s, want the stream to end", ev.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open")
+	}
+	resp = client.do(context.Background(), http.MethodPost, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, "application/json", nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST after shutdown: status %d", resp.StatusCode)
+	}
+}
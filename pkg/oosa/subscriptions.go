@@ -0,0 +1,387 @@
+package oosa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+	methodResourcesUpdated     = "notifications/resources/updated"
+
+	// stdioSessionID is the ID mcp-go gives its single stdio session.
+	stdioSessionID = "stdio"
+	// maxMessageSize bounds the JSON-RPC messages accepted over HTTP; larger
+	// ones are refused with 413 rather than cut short.
+	maxMessageSize = 1 << 20
+)
+
+// Subscriptions tracks which sessions watch which event resources and sends
+// them notifications/resources/updated when an event changes, whether through
+// a tool of this server or directly in the backend.
+//
+// mcp-go advertises resource subscriptions but does not answer
+// resources/subscribe, so those requests are taken off the transport before
+// they reach the MCP server: see SSEHandler, StdioTransport and StreamableHTTP.
+type Subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]server.ClientSession
+	// uris holds the subscribed URIs of each session.
+	uris map[string]map[string]struct{}
+	// digests fingerprints the last state seen of each event.
+	digests map[string]uint64
+}
+
+// NewSubscriptions creates an empty Subscriptions.
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		sessions: make(map[string]server.ClientSession),
+		uris:     make(map[string]map[string]struct{}),
+		digests:  make(map[string]uint64),
+	}
+}
+
+// WithSubscriptions lets clients subscribe to event resources through subs.
+func WithSubscriptions(subs *Subscriptions) ServerOption {
+	return func(o *serverOptions) {
+		o.subscriptions = subs
+	}
+}
+
+// registerSession tracks a session until the context it was registered
+// with ends, which is when its connection closes. It fits
+// server.OnRegisterSessionHookFunc.
+func (s *Subscriptions) registerSession(ctx context.Context, session server.ClientSession) {
+	id := session.SessionID()
+
+	s.mu.Lock()
+	s.sessions[id] = session
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.sessions[id] == session {
+			delete(s.sessions, id)
+			delete(s.uris, id)
+		}
+	}()
+}
+
+func (s *Subscriptions) hasSession(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[sessionID]
+	return ok
+}
+
+// subscribable reports whether uri names a resource that sends updates. An
+// event's calendar file counts, as it changes with the event.
+func subscribable(uri string) bool {
+	if uri == EventsResourceURI {
+		return true
+	}
+	id, ok := strings.CutPrefix(uri, EventResourceURI(""))
+	return ok && id != "" && !strings.Contains(id, "/")
+}
+
+// Subscribe starts sending updates of uri to a session.
+func (s *Subscriptions) Subscribe(sessionID, uri string) error {
+	if !subscribable(uri) {
+		return fmt.Errorf("cannot subscribe to %q: only %s, %s and %s send updates", uri, EventsResourceURI, EventResourceURITemplate, EventICSResourceURITemplate)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[sessionID]; !ok {
+		return fmt.Errorf("unknown session %s", sessionID)
+	}
+	if s.uris[sessionID] == nil {
+		s.uris[sessionID] = make(map[string]struct{})
+	}
+	s.uris[sessionID][uri] = struct{}{}
+	return nil
+}
+
+// Unsubscribe stops sending updates of uri to a session.
+func (s *Subscriptions) Unsubscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uris[sessionID], uri)
+}
+
+// active reports whether any session subscribed to anything.
+func (s *Subscriptions) active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, uris := range s.uris {
+		if len(uris) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends notifications/resources/updated for uri to every session
+// subscribed to it. Sessions whose notification queue is full miss it.
+func (s *Subscriptions) Notify(uri string) {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: methodResourcesUpdated,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{"uri": uri},
+			},
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, uris := range s.uris {
+		if _, ok := uris[uri]; !ok {
+			continue
+		}
+		session, ok := s.sessions[id]
+		if !ok || !session.Initialized() {
+			continue
+		}
+		select {
+		case session.NotificationChannel() <- notification:
+		default:
+		}
+	}
+}
+
+// eventDigest fingerprints every field of an event.
+func eventDigest(e Event) uint64 {
+	data, _ := json.Marshal(e)
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+// observe records the current state of an event and notifies its
+// subscribers, and those of the event list, when it differs from the last
+// state seen. An event seen for the first time only counts as a change when
+// announceNew is set.
+func (s *Subscriptions) observe(e Event, announceNew bool) {
+	digest := eventDigest(e)
+
+	s.mu.Lock()
+	prev, seen := s.digests[e.ID]
+	s.digests[e.ID] = digest
+	s.mu.Unlock()
+
+	if (seen && prev != digest) || (!seen && announceNew) {
+		s.Notify(EventResourceURI(e.ID))
+		s.Notify(EventICSResourceURI(e.ID))
+		s.Notify(EventsResourceURI)
+	}
+}
+
+// sync compares the complete current set of events with the last states seen.
+func (s *Subscriptions) sync(events []Event, announceNew bool) {
+	current := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		current[e.ID] = struct{}{}
+		s.observe(e, announceNew)
+	}
+
+	var gone []string
+	s.mu.Lock()
+	for id := range s.digests {
+		if _, ok := current[id]; !ok {
+			gone = append(gone, id)
+			delete(s.digests, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range gone {
+		s.Notify(EventResourceURI(id))
+		s.Notify(EventICSResourceURI(id))
+	}
+	if len(gone) > 0 {
+		s.Notify(EventsResourceURI)
+	}
+}
+
+// Watch polls store every interval for changes made outside this server,
+// such as seats taken through the OOSA app, until ctx is done. Polling is
+// skipped while nobody is subscribed. Failed polls are passed to onError and
+// retried on the next tick.
+func (s *Subscriptions) Watch(ctx context.Context, store EventStore, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		return
+	}
+
+	primed := false
+	poll := func() {
+		events, err := store.GetEvents(ctx, EventFilter{})
+		if err != nil {
+			if ctx.Err() == nil && onError != nil {
+				onError(fmt.Errorf("failed to poll events: %w", err))
+			}
+			return
+		}
+		s.sync(events, primed)
+		primed = true
+	}
+	poll()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.active() {
+				poll()
+			}
+		}
+	}
+}
+
+// handleMessage answers resources/subscribe and resources/unsubscribe for a
+// session. It reports false for every other message, which is left to the
+// MCP server.
+func (s *Subscriptions) handleMessage(sessionID string, message []byte) (mcp.JSONRPCMessage, bool) {
+	var request struct {
+		ID     mcp.RequestId `json:"id"`
+		Method string        `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &request); err != nil || request.ID == nil {
+		return nil, false
+	}
+
+	switch request.Method {
+	case methodResourcesSubscribe:
+		if err := s.Subscribe(sessionID, request.Params.URI); err != nil {
+			response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID}
+			response.Error.Code = mcp.INVALID_PARAMS
+			response.Error.Message = err.Error()
+			return response, true
+		}
+	case methodResourcesUnsubscribe:
+		s.Unsubscribe(sessionID, request.Params.URI)
+	default:
+		return nil, false
+	}
+
+	return mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      request.ID,
+		Result:  mcp.EmptyResult{},
+	}, true
+}
+
+// SSEHandler wraps sse so that subscription requests posted to its message
+// endpoint are answered by s. The response goes out on the session's event
+// stream and in the HTTP response, as mcp-go does for other requests.
+func (s *Subscriptions) SSEHandler(sse *server.SSEServer) http.Handler {
+	messagePath := sse.CompleteMessagePath()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != messagePath {
+			sse.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxMessageSize {
+			writeHTTPError(w, http.StatusRequestEntityTooLarge, mcp.INVALID_REQUEST, "message too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Unknown sessions are left for mcp-go to reject.
+		sessionID := r.URL.Query().Get("sessionId")
+		if !s.hasSession(sessionID) {
+			sse.ServeHTTP(w, r)
+			return
+		}
+		response, ok := s.handleMessage(sessionID, body)
+		if !ok {
+			sse.ServeHTTP(w, r)
+			return
+		}
+
+		_ = sse.SendEventToSession(sessionID, response)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
+
+// StreamableHTTP lets s answer the subscription requests posted to a
+// StreamableHTTPServer. Updates go out on the session's notification stream.
+func (s *Subscriptions) StreamableHTTP() StreamableHTTPOption {
+	return func(h *StreamableHTTPServer) {
+		h.intercept = s.handleMessage
+	}
+}
+
+// syncWriter serialises writes, so that lines written by s and by the stdio
+// server do not interleave.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// StdioTransport filters the streams of a stdio server: subscription requests
+// read from in are answered by s on out, and everything else is passed on
+// through the returned reader. The returned writer must be used as the stdio
+// server's output.
+func (s *Subscriptions) StdioTransport(in io.Reader, out io.Writer) (io.Reader, io.Writer) {
+	writer := &syncWriter{w: out}
+	pr, pw := io.Pipe()
+
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if response, ok := s.handleMessage(stdioSessionID, line); ok {
+					data, _ := json.Marshal(response)
+					_, _ = writer.Write(append(data, '\n'))
+				} else if _, err := pw.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	return pr, writer
+}
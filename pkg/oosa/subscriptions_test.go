@@ -0,0 +1,82 @@
+package oosa
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// openSSESession connects to the SSE endpoint of srv and returns the URL
+// to post messages of the new session to.
+func openSSESession(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/sse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		if endpoint, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			if strings.HasPrefix(endpoint, "/") {
+				endpoint = srv.URL + endpoint
+			}
+			return endpoint
+		}
+	}
+	t.Fatalf("no endpoint event: %v", lines.Err())
+	return ""
+}
+
+func TestSSEHandlerMessageSize(t *testing.T) {
+	subs := NewSubscriptions()
+	mcpServer := NewServer(NewMockClient(), "test", WithSubscriptions(subs))
+	sse := server.NewSSEServer(mcpServer)
+	srv := httptest.NewServer(subs.SSEHandler(sse))
+	t.Cleanup(srv.Close)
+	endpoint := openSSESession(t, srv)
+
+	const message = `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"oosa://events/event1"}}`
+	subscribe := func(padding int) *http.Response {
+		t.Helper()
+		body := message + strings.Repeat(" ", padding)
+		resp, err := http.Post(endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	if resp := subscribe(0); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("subscribe: status %d", resp.StatusCode)
+	}
+
+	// Padded to the limit the message still goes through; one byte more and
+	// it is refused rather than cut short into invalid JSON.
+	if resp := subscribe(maxMessageSize - len(message)); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("message at the limit: status %d", resp.StatusCode)
+	}
+	resp := subscribe(maxMessageSize - len(message) + 1)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized message: status %d", resp.StatusCode)
+	}
+	var refusal mcp.JSONRPCError
+	if err := json.NewDecoder(resp.Body).Decode(&refusal); err != nil || refusal.Error.Code != mcp.INVALID_REQUEST {
+		t.Errorf("oversized message: %+v, %v", refusal, err)
+	}
+}
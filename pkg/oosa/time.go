@@ -0,0 +1,136 @@
+package oosa
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Embedded so any IANA zone passed as "timezone" loads, even on hosts
+	// without a zoneinfo database.
+	_ "time/tzdata"
+)
+
+// displayLayout is how times are written for people to read.
+const displayLayout = "2006-01-02 (Mon) 15:04"
+
+// Time is a timestamp of an event or idea. On the wire it is an RFC3339
+// string, with "" for no time, as the OOSA API has always sent it.
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps t.
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// ParseTime parses an RFC3339 timestamp. The empty string is the zero Time.
+func ParseTime(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Time{}, fmt.Errorf("expected an RFC3339 timestamp (2025-04-11T16:00:00+08:00)")
+	}
+	return Time{Time: t}, nil
+}
+
+// mustParseTime is ParseTime for timestamps known to be valid, such as the
+// mock data.
+func mustParseTime(s string) Time {
+	t, err := ParseTime(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid time %q: %v", s, err))
+	}
+	return t
+}
+
+// timePtr returns a pointer to t, for the optional timestamps.
+func timePtr(t Time) *Time {
+	return &t
+}
+
+// String returns t in RFC3339, or "" for the zero Time.
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// Display returns t in loc for people to read, or "" for the zero Time.
+func (t Time) Display(loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(displayLayout)
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*t = Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("time must be a string: %w", err)
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: %w", s, err)
+	}
+	*t = parsed
+	return nil
+}
+
+// parseTimezone loads the zone named by a "timezone" argument. The empty
+// string is Asia/Taipei, where OOSA events take place.
+func parseTimezone(name string) (*time.Location, error) {
+	switch name = strings.TrimSpace(name); name {
+	case "", taipei.String():
+		return taipei, nil
+	case "Local":
+		// The zone of the server means nothing to the client.
+		return nil, fmt.Errorf("invalid timezone %q: expected an IANA name such as Asia/Taipei or UTC", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: expected an IANA name such as Asia/Taipei or UTC", name)
+	}
+	return loc, nil
+}
+
+// formatDuration writes d the short way people read it: 4h, 1h30m, 2d4h.
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0m"
+	}
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%dd", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+	}
+	if b.Len() == 0 {
+		return "0m"
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package oosa
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeWireFormat(t *testing.T) {
+	for _, wire := range []string{`"2025-04-11T16:00:00Z"`, `"2025-04-12T00:00:00+08:00"`, `"2025-04-11T16:00:00.5Z"`, `""`} {
+		var v Time
+		if err := json.Unmarshal([]byte(wire), &v); err != nil {
+			t.Fatalf("%s: %v", wire, err)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != wire {
+			t.Errorf("%s came back as %s", wire, data)
+		}
+	}
+
+	var v Time
+	if err := json.Unmarshal([]byte(`null`), &v); err != nil || !v.IsZero() {
+		t.Errorf("null: %v, %v", v, err)
+	}
+	for _, bad := range []string{`"2025-04-11 16:00"`, `1744387200`} {
+		if err := json.Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+
+	// An event keeps the times the OOSA API sent.
+	const api = `{"events_id":"e","events_date":"2025-04-11T16:00:00Z","events_date_end":"","events_deadline":"2025-04-10T23:59:59Z","events_created_at":"2025-03-11T12:00:00Z"}`
+	var e Event
+	if err := json.Unmarshal([]byte(api), &e); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Date.Equal(time.Date(2025, 4, 11, 16, 0, 0, 0, time.UTC)) || !e.DateEnd.IsZero() || e.CreatedAt == nil {
+		t.Errorf("decoded %v %v %v", e.Date, e.DateEnd, e.CreatedAt)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"events_date":"2025-04-11T16:00:00Z"`, `"events_date_end":""`, `"events_deadline":"2025-04-10T23:59:59Z"`, `"events_created_at":"2025-03-11T12:00:00Z"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("%s lacks %s", data, field)
+		}
+	}
+}
+
+func TestTimezoneArgument(t *testing.T) {
+	client := newTestClient(t, NewMockClient())
+	get := func(timezone string) (map[string]any, string, bool) {
+		t.Helper()
+		args := map[string]any{"events_id": "event1", "format": "json"}
+		if timezone != "" {
+			args["timezone"] = timezone
+		}
+		text, isError := client.tryTool("get_event", args)
+		var fields map[string]any
+		if !isError {
+			if err := json.Unmarshal([]byte(text), &fields); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return fields, text, isError
+	}
+
+	for timezone, want := range map[string]string{
+		"":                 "2025-04-12T00:00:00+08:00",
+		"Asia/Taipei":      "2025-04-12T00:00:00+08:00",
+		"UTC":              "2025-04-11T16:00:00Z",
+		"America/New_York": "2025-04-11T12:00:00-04:00",
+		"Asia/Tokyo":       "2025-04-12T01:00:00+09:00",
+	} {
+		fields, text, isError := get(timezone)
+		if isError {
+			t.Errorf("timezone %q: %s", timezone, text)
+			continue
+		}
+		if fields["events_date"] != want {
+			t.Errorf("timezone %q: events_date %v, want %s", timezone, fields["events_date"], want)
+		}
+	}
+
+	for _, timezone := range []string{"Mars/Olympus_Mons", "Local", "+08:00"} {
+		if _, text, isError := get(timezone); !isError || !strings.Contains(text, `"code":"invalid_argument"`) || !strings.Contains(text, "invalid timezone") {
+			t.Errorf("timezone %q: %s", timezone, text)
+		}
+	}
+}
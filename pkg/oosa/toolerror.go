@@ -0,0 +1,228 @@
+package oosa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrorCode classifies why a tool failed, so clients can react to it in code
+// rather than by parsing messages.
+type ErrorCode string
+
+const (
+	// CodeInvalidArgument means the arguments are wrong; fix them before retrying.
+	CodeInvalidArgument ErrorCode = "invalid_argument"
+	// CodeNotFound means the event or user does not exist.
+	CodeNotFound ErrorCode = "not_found"
+	// CodeUnauthenticated means the tool needs a signed-in user.
+	CodeUnauthenticated ErrorCode = "unauthenticated"
+	// CodePermissionDenied means the user may not do this, e.g. change an
+	// event they do not host.
+	CodePermissionDenied ErrorCode = "permission_denied"
+	// CodeFailedPrecondition means the event is not in a state that allows
+	// the change, e.g. it is full or its deadline has passed.
+	CodeFailedPrecondition ErrorCode = "failed_precondition"
+	// CodeUnavailable means the backend could not be reached; try again later.
+	CodeUnavailable ErrorCode = "unavailable"
+	// CodeDeadlineExceeded means the backend took too long; try again later.
+	CodeDeadlineExceeded ErrorCode = "deadline_exceeded"
+	// CodeInternal means something failed that the caller cannot fix.
+	CodeInternal ErrorCode = "internal"
+)
+
+// retryable reports whether the same call may succeed later.
+func (c ErrorCode) retryable() bool {
+	return c == CodeUnavailable || c == CodeDeadlineExceeded
+}
+
+// ToolError is the error every tool reports. It is sent as the text of an
+// error result, as the JSON {"error": {...}}. Message carries the details of
+// the failure, which come in English; MessageZH only says what went wrong in
+// words a zh-TW user can read.
+type ToolError struct {
+	Code      ErrorCode `json:"code"`
+	Retryable bool      `json:"retryable"`
+	Message   string    `json:"message"`
+	MessageZH string    `json:"message_zh_tw"`
+}
+
+func newToolError(code ErrorCode, message, messageZH string) *ToolError {
+	return &ToolError{
+		Code:      code,
+		Retryable: code.retryable(),
+		Message:   message,
+		MessageZH: messageZH,
+	}
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Result wraps e in a tool result flagged as an error.
+func (e *ToolError) Result() *mcp.CallToolResult {
+	data, err := json.Marshal(struct {
+		Error *ToolError `json:"error"`
+	}{e})
+	if err != nil {
+		return mcp.NewToolResultError(e.Error())
+	}
+	return mcp.NewToolResultError(string(data))
+}
+
+// invalidArgument reports arguments that failed to parse or validate.
+func invalidArgument(err error) *ToolError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return newToolError(CodeInvalidArgument, err.Error(),
+			fmt.Sprintf("活動資料有 %d 項不符合規定：%s。請修正後再試", len(validationErr.Problems), strings.Join(validationErr.ProblemsZH, "；")))
+	}
+	return newToolError(CodeInvalidArgument, err.Error(), "參數不正確，請修正後再試")
+}
+
+// eventNotFound reports an event ID that matches no event.
+func eventNotFound(eventID string) *ToolError {
+	return newToolError(CodeNotFound,
+		fmt.Sprintf("event not found: %s", eventID),
+		fmt.Sprintf("找不到活動：%s", eventID))
+}
+
+// actionsZH names the changes made to an event in Traditional Chinese.
+var actionsZH = map[string]string{
+	"create": "建立",
+	"update": "修改",
+	"cancel": "取消",
+	"join":   "報名",
+	"leave":  "退出",
+}
+
+// onlyHost refuses a change to an event by someone other than its host.
+func onlyHost(eventID, action string) *ToolError {
+	return newToolError(CodePermissionDenied,
+		fmt.Sprintf("only the host of event %s can %s it", eventID, action),
+		fmt.Sprintf("只有活動 %s 的主辦人可以%s", eventID, actionsZH[action]))
+}
+
+// eventRefusals are the EventWriter errors that refuse a change because of
+// the state of the event, with their Traditional Chinese wording.
+var eventRefusals = []struct {
+	err error
+	zh  string
+}{
+	{ErrEventCancelled, "活動已取消"},
+	{ErrLimitBelowParticipants, "人數上限低於目前的報名人數"},
+	{ErrEventFull, "活動名額已滿"},
+	{ErrRegistrationClosed, "報名已截止"},
+	{ErrEventStarted, "活動已經開始"},
+	{ErrAlreadyJoined, "已經報名過這個活動"},
+	{ErrNotJoined, "沒有報名這個活動"},
+}
+
+// eventRefusal reports a change refused by the state of an event. It returns
+// nil when err is not one of eventRefusals.
+func eventRefusal(action, eventID string, err error) *ToolError {
+	for _, refusal := range eventRefusals {
+		if errors.Is(err, refusal.err) {
+			return newToolError(CodeFailedPrecondition,
+				fmt.Sprintf("cannot %s event %s: %s", action, eventID, refusal.err),
+				fmt.Sprintf("無法%s活動 %s：%s", actionsZH[action], eventID, refusal.zh))
+		}
+	}
+	return nil
+}
+
+// callerError reports a failure to resolve the user calling a tool.
+func callerError(err error) *ToolError {
+	if errors.Is(err, errUnauthenticated) {
+		return newToolError(CodeUnauthenticated, err.Error(), "這個工具需要登入的使用者")
+	}
+	return backendError(err, "failed to resolve caller", "無法確認使用者身分")
+}
+
+// eventLookupError reports a failure to get an event.
+func eventLookupError(eventID string, err error) *ToolError {
+	if errors.Is(err, ErrNotFound) {
+		return eventNotFound(eventID)
+	}
+	return backendError(err, "failed to get event", "無法取得活動")
+}
+
+// internalError reports a failure of this server, such as a result that
+// cannot be encoded.
+func internalError(err error) *ToolError {
+	return newToolError(CodeInternal, err.Error(), "伺服器內部錯誤")
+}
+
+// backendError reports a failed backend call, telling apart the failures
+// worth retrying. message and messageZH say what was being done.
+func backendError(err error, message, messageZH string) *ToolError {
+	code := CodeInternal
+	var apiErr *APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrNotFound):
+		code = CodeNotFound
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		code = CodeDeadlineExceeded
+	case errors.As(err, &apiErr):
+		code = apiErr.code()
+	case errors.As(err, &netErr):
+		code = CodeUnavailable
+		if netErr.Timeout() {
+			code = CodeDeadlineExceeded
+		}
+	case mongo.IsNetworkError(err):
+		code = CodeUnavailable
+	}
+	if reason, ok := codeReasonsZH[code]; ok {
+		messageZH += "：" + reason
+	}
+	return newToolError(code, message+": "+err.Error(), messageZH)
+}
+
+// codeReasonsZH explains in Traditional Chinese why a backend call failed,
+// in place of the backend's own error text.
+var codeReasonsZH = map[ErrorCode]string{
+	CodeInvalidArgument:    "後端不接受這些資料",
+	CodeNotFound:           "找不到資料",
+	CodeUnauthenticated:    "後端拒絕了伺服器的憑證",
+	CodePermissionDenied:   "沒有權限",
+	CodeFailedPrecondition: "活動目前的狀態不允許這項操作",
+	CodeUnavailable:        "後端服務暫時無法連線，請稍後再試",
+	CodeDeadlineExceeded:   "後端服務回應逾時，請稍後再試",
+}
+
+// code classifies the status of an OOSA API response.
+func (e *APIError) code() ErrorCode {
+	if e.Unwrap() != nil {
+		return CodeFailedPrecondition
+	}
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeFailedPrecondition
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeDeadlineExceeded
+	case http.StatusTooManyRequests:
+		return CodeUnavailable
+	}
+	if e.StatusCode >= 500 {
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
@@ -0,0 +1,95 @@
+package oosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// latin finds English words, which have no place in a zh-TW message.
+var latin = regexp.MustCompile(`[A-Za-z]{2,}`)
+
+func TestToolErrorMessagesZH(t *testing.T) {
+	unnamed := mockEvent(t, "event1").Event
+	unnamed.Name, unnamed.Place = "", ""
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name     string
+		toolErr  *ToolError
+		cause    error
+		code     ErrorCode
+		reasonZH string
+	}{
+		{"bad argument", invalidArgument(errors.New("parameter events_lat is not of type number, is string")),
+			nil, CodeInvalidArgument, "參數不正確"},
+		{"invalid event", invalidArgument(validateEvent(unnamed, DefaultBounds)),
+			nil, CodeInvalidArgument, "2 項不符合規定：請填寫活動名稱；請填寫活動地點。"},
+		{"internal", internalError(errors.New("json: unsupported value: NaN")),
+			nil, CodeInternal, "伺服器內部錯誤"},
+		{"unreachable", backendError(fmt.Errorf("failed to call oosa api: %w", dialErr), "failed to get events", "無法取得活動列表"),
+			dialErr, CodeUnavailable, "無法取得活動列表：後端服務暫時無法連線"},
+		{"timeout", backendError(context.DeadlineExceeded, "failed to get ideas", "無法取得提案"),
+			context.DeadlineExceeded, CodeDeadlineExceeded, "無法取得提案：後端服務回應逾時"},
+		{"api refusal", backendError(&APIError{StatusCode: http.StatusConflict, Message: "Event is locked"}, "failed to join event", "無法報名活動"),
+			nil, CodeFailedPrecondition, "無法報名活動：活動目前的狀態不允許這項操作"},
+		{"internal backend", backendError(errors.New("cursor closed"), "failed to create event", "無法建立活動"),
+			nil, CodeInternal, "無法建立活動"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.toolErr
+			if e.Code != tt.code {
+				t.Errorf("code %s, want %s", e.Code, tt.code)
+			}
+			if !strings.Contains(e.MessageZH, tt.reasonZH) {
+				t.Errorf("message_zh_tw %q, want %q in it", e.MessageZH, tt.reasonZH)
+			}
+			if word := latin.FindString(e.MessageZH); word != "" {
+				t.Errorf("message_zh_tw %q has English %q", e.MessageZH, word)
+			}
+			// The details stay available, in the English message.
+			if tt.cause != nil && !strings.Contains(e.Message, tt.cause.Error()) {
+				t.Errorf("message %q lost %q", e.Message, tt.cause)
+			}
+		})
+	}
+}
+
+func TestValidationMessageZH(t *testing.T) {
+	now := mustParseTime("2025-04-20T00:00:00Z").Time
+	// event1 is over by now; on top of that it breaks every other rule.
+	broken := mockEvent(t, "event1").Event
+	broken.Name, broken.Place = " ", ""
+	broken.Deadline = broken.DateEnd
+	broken.ParticipantLimit = 2.5
+	broken.PaymentRequired, broken.PaymentFee = 1, 0
+	broken.Lat, broken.Lng = 35.68, 139.76
+	broken.MeetingPointLat, broken.MeetingPointLng = 35.68, 139.70
+
+	var invalid *ValidationError
+	if !errors.As(validateNewEvent(broken, DefaultBounds, now), &invalid) {
+		t.Fatal("broken event passed")
+	}
+	if len(invalid.ProblemsZH) != len(invalid.Problems) {
+		t.Fatalf("%d problems, %d in Chinese", len(invalid.Problems), len(invalid.ProblemsZH))
+	}
+
+	messageZH := invalidArgument(invalid).MessageZH
+	if word := latin.FindString(messageZH); word != "" {
+		t.Errorf("message_zh_tw %q has English %q", messageZH, word)
+	}
+	for _, want := range []string{
+		"活動資料有 9 項不符合規定：",
+		"請填寫活動名稱；請填寫活動地點；報名截止時間必須早於開始時間；人數上限必須是正整數；需要付費的活動，費用必須大於 0；",
+		"活動地點不在開放的範圍內；集合地點不在開放的範圍內；開始時間已經過去；報名截止時間已經過去。",
+	} {
+		if !strings.Contains(messageZH, want) {
+			t.Errorf("message_zh_tw %q, want %q in it", messageZH, want)
+		}
+	}
+}
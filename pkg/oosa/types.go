@@ -0,0 +1,79 @@
+package oosa
+
+type Event struct {
+	ID               string              `json:"events_id"`
+	Name             string              `json:"events_name"`
+	Date             Time                `json:"events_date"`
+	DateEnd          Time                `json:"events_date_end"`
+	Deadline         Time                `json:"events_deadline"`
+	Place            string              `json:"events_place"`
+	Lat              float64             `json:"events_lat"`
+	Lng              float64             `json:"events_lng"`
+	MeetingPointName string              `json:"events_meeting_point_name"`
+	MeetingPointLat  float64             `json:"events_meeting_point_lat"`
+	MeetingPointLng  float64             `json:"events_meeting_point_lng"`
+	ParticipantLimit float64             `json:"events_participant_limit"`
+	PaymentRequired  int64               `json:"events_payment_required"`
+	PaymentFee       float64             `json:"events_payment_fee"`
+	Photo            string              `json:"events_photo"`
+	Type             string              `json:"events_type"`
+	CreatedByUser    *UserAgg            `json:"events_created_by_user,omitempty"`
+	Participants     *EventsParticipants `json:"events_participants,omitempty"`
+	CreatedAt        *Time               `json:"events_created_at,omitempty"`
+	Status           EventStatus         `json:"events_status"`
+	CancelReason     string              `json:"events_cancel_reason,omitempty"`
+	CancelledAt      *Time               `json:"events_cancelled_at,omitempty"`
+}
+
+// EventStatus tells whether an event still takes place.
+type EventStatus string
+
+const (
+	// EventStatusActive is an event that takes place as planned
+	EventStatusActive EventStatus = "active"
+	// EventStatusCancelled is an event called off by its host
+	EventStatusCancelled EventStatus = "cancelled"
+)
+
+type UserAgg struct {
+	ID   string `json:"user_id"`
+	Name string `json:"user_name"`
+	// Email is private: backends may fill it in, but it is never part of
+	// what tools and resources return, and it is not read from JSON either.
+	Email  string `json:"-"`
+	Avatar string `json:"user_avatar"`
+}
+
+type EventsParticipants struct {
+	LatestThreeUser []UserAgg `json:"latest_tree_user"`
+	RemainNumber    int64     `json:"remain_number"`
+}
+
+// EventDetail is the complete view of one event, including the data that is
+// too heavy for listings.
+type EventDetail struct {
+	Event
+	Description     string       `json:"events_description"`
+	Host            *UserProfile `json:"events_host,omitempty"`
+	ParticipantList []UserAgg    `json:"events_participant_list"`
+}
+
+// UserProfile is a user together with their hosting record.
+type UserProfile struct {
+	UserAgg
+	EventsHosted int `json:"user_events_hosted"`
+}
+
+// Idea is an outing proposed by a user, not yet scheduled as an event.
+type Idea struct {
+	ID            string   `json:"ideas_id"`
+	Title         string   `json:"ideas_title"`
+	Description   string   `json:"ideas_description"`
+	Date          Time     `json:"ideas_date"`
+	DateEnd       Time     `json:"ideas_date_end"`
+	Place         string   `json:"ideas_place"`
+	Lat           float64  `json:"ideas_lat"`
+	Lng           float64  `json:"ideas_lng"`
+	CreatedByUser *UserAgg `json:"ideas_created_by_user,omitempty"`
+	CreatedAt     *Time    `json:"ideas_created_at,omitempty"`
+}
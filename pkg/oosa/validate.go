@@ -0,0 +1,125 @@
+package oosa
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Bounds is the area, in degrees, events may take place in.
+type Bounds struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// DefaultBounds covers Taiwan together with Penghu, Kinmen and Matsu.
+var DefaultBounds = Bounds{MinLat: 21.8, MinLng: 118.0, MaxLat: 26.4, MaxLng: 122.1}
+
+// Contains reports whether lat/lng lies inside b.
+func (b Bounds) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+func (b Bounds) String() string {
+	return fmt.Sprintf("%v,%v to %v,%v", b.MinLat, b.MinLng, b.MaxLat, b.MaxLng)
+}
+
+// ValidationError lists every rule an event breaks.
+type ValidationError struct {
+	Problems []string
+	// ProblemsZH says the same as Problems, one for one, in Traditional
+	// Chinese and without the field names.
+	ProblemsZH []string
+}
+
+// add records a broken rule, in English and in Traditional Chinese.
+func (e *ValidationError) add(problem, problemZH string) {
+	e.Problems = append(e.Problems, problem)
+	e.ProblemsZH = append(e.ProblemsZH, problemZH)
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid event: " + strings.Join(e.Problems, "; ")
+}
+
+// validateEvent checks e against the OOSA event rules: the registration
+// deadline comes before the start, which comes before the end; the
+// participant limit is a positive whole number; a fee is charged exactly when
+// payment is required; and every location lies inside bounds.
+func validateEvent(e Event, bounds Bounds) error {
+	invalid := &ValidationError{}
+
+	if strings.TrimSpace(e.Name) == "" {
+		invalid.add("events_name is required", "請填寫活動名稱")
+	}
+	if strings.TrimSpace(e.Place) == "" {
+		invalid.add("events_place is required", "請填寫活動地點")
+	}
+
+	if e.Date.IsZero() {
+		invalid.add("events_date is required", "請填寫開始時間")
+	}
+	if e.DateEnd.IsZero() {
+		invalid.add("events_date_end is required", "請填寫結束時間")
+	}
+	if e.Deadline.IsZero() {
+		invalid.add("events_deadline is required", "請填寫報名截止時間")
+	}
+	if !e.Deadline.IsZero() && !e.Date.IsZero() && !e.Deadline.Before(e.Date.Time) {
+		invalid.add("events_deadline must be before events_date", "報名截止時間必須早於開始時間")
+	}
+	if !e.Date.IsZero() && !e.DateEnd.IsZero() && !e.Date.Before(e.DateEnd.Time) {
+		invalid.add("events_date must be before events_date_end", "開始時間必須早於結束時間")
+	}
+
+	if e.ParticipantLimit <= 0 || e.ParticipantLimit != math.Trunc(e.ParticipantLimit) {
+		invalid.add(fmt.Sprintf("events_participant_limit must be a positive whole number, is %v", e.ParticipantLimit), "人數上限必須是正整數")
+	}
+
+	switch {
+	case e.PaymentFee < 0:
+		invalid.add(fmt.Sprintf("events_payment_fee must not be negative, is %v", e.PaymentFee), "費用不可為負數")
+	case e.PaymentRequired != 0 && e.PaymentFee == 0:
+		invalid.add("events_payment_fee must be greater than 0 when payment is required", "需要付費的活動，費用必須大於 0")
+	case e.PaymentRequired == 0 && e.PaymentFee > 0:
+		invalid.add("events_payment_fee must be 0 when payment is not required", "不需付費的活動，費用必須為 0")
+	}
+
+	if !bounds.Contains(e.Lat, e.Lng) {
+		invalid.add(fmt.Sprintf("events_lat/events_lng %v,%v is outside the allowed area %s", e.Lat, e.Lng, bounds), "活動地點不在開放的範圍內")
+	}
+	if (e.MeetingPointLat != 0 || e.MeetingPointLng != 0) && !bounds.Contains(e.MeetingPointLat, e.MeetingPointLng) {
+		invalid.add(fmt.Sprintf("events_meeting_point_lat/events_meeting_point_lng %v,%v is outside the allowed area %s", e.MeetingPointLat, e.MeetingPointLng, bounds), "集合地點不在開放的範圍內")
+	}
+
+	if len(invalid.Problems) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+// validateNewEvent is validateEvent for an event about to be published: on
+// top of the rules for every event, it must not start or close registration
+// before now.
+func validateNewEvent(e Event, bounds Bounds, now time.Time) error {
+	var invalid *ValidationError
+	if !errors.As(validateEvent(e, bounds), &invalid) {
+		invalid = &ValidationError{}
+	}
+
+	if !e.Date.IsZero() && !e.Date.After(now) {
+		invalid.add(fmt.Sprintf("events_date %s is in the past", e.Date.Format(time.RFC3339)), "開始時間已經過去")
+	}
+	if !e.Deadline.IsZero() && !e.Deadline.After(now) {
+		invalid.add(fmt.Sprintf("events_deadline %s is in the past", e.Deadline.Format(time.RFC3339)), "報名截止時間已經過去")
+	}
+
+	if len(invalid.Problems) > 0 {
+		return invalid
+	}
+	return nil
+}